@@ -0,0 +1,261 @@
+package gorma
+
+import (
+	"fmt"
+	"go/token"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/Gys/goa/design"
+	"github.com/Gys/goa/dslengine"
+	"github.com/Gys/goa/goagen/codegen"
+)
+
+// Context returns the generic definition name used in error messages.
+func (a *MediaTypeAdapterDefinition) Context() string {
+	return adapterContext("MediaTypeAdapter", a.Name)
+}
+
+// DSL returns this object's DSL.
+func (a *MediaTypeAdapterDefinition) DSL() func() {
+	return a.DefinitionDSL
+}
+
+// Validate tests whether the adapter definition is consistent: its
+// transforms compute attributes of the media type.
+func (a *MediaTypeAdapterDefinition) Validate() error {
+	if a.Left == nil {
+		return adapterError(a, "missing media type")
+	}
+	return validateTransforms(a, a.Name, a.Transforms, func(target string) bool {
+		_, ok := a.Left.ToObject()[target]
+		return ok
+	})
+}
+
+// Assignments returns the code of the adapter setting the attributes of the
+// media type held by the v variable from the model held by the src
+// variable: the attributes of the default view named after fields, then
+// the transforms.
+func (a *MediaTypeAdapterDefinition) Assignments(v string) string {
+	var code []string
+	if view, ok := a.Left.Views["default"]; ok {
+		code = append(code, fieldAssignmentModelToType(a.Right, view, "src", "src", v))
+	}
+	for _, t := range a.Transforms {
+		code = append(code, fmt.Sprintf("%s.%s = %s", v, codegen.Goify(t.Target, true), t.value("src")))
+	}
+	return strings.Join(code, "\n")
+}
+
+// Context returns the generic definition name used in error messages.
+func (a *UserTypeAdapterDefinition) Context() string {
+	return adapterContext("UserTypeAdapter", a.Name)
+}
+
+// DSL returns this object's DSL.
+func (a *UserTypeAdapterDefinition) DSL() func() {
+	return a.DefinitionDSL
+}
+
+// Validate tests whether the adapter definition is consistent: its
+// transforms compute fields of the model.
+func (a *UserTypeAdapterDefinition) Validate() error {
+	if a.Left == nil {
+		return adapterError(a, "missing user type")
+	}
+	return validateTransforms(a, a.Name, a.Transforms, modelTarget(a.Right))
+}
+
+// Assignments returns the code of the adapter setting the fields of the
+// model m from the user type held by the src variable: the fields named
+// after attributes, then the transforms.
+func (a *UserTypeAdapterDefinition) Assignments() string {
+	return adapterAssignments(a.Right, a.Left, a.Transforms)
+}
+
+// Context returns the generic definition name used in error messages.
+func (a *PayloadAdapterDefinition) Context() string {
+	return adapterContext("PayloadAdapter", a.Name)
+}
+
+// DSL returns this object's DSL.
+func (a *PayloadAdapterDefinition) DSL() func() {
+	return a.DefinitionDSL
+}
+
+// Validate tests whether the adapter definition is consistent: its
+// transforms compute fields of the model.
+func (a *PayloadAdapterDefinition) Validate() error {
+	if a.Left == nil {
+		return adapterError(a, "missing payload")
+	}
+	return validateTransforms(a, a.Name, a.Transforms, modelTarget(a.Right))
+}
+
+// Assignments returns the code of the adapter setting the fields of the
+// model m from the payload held by the src variable, see
+// UserTypeAdapterDefinition.Assignments.
+func (a *PayloadAdapterDefinition) Assignments() string {
+	return adapterAssignments(a.Right, a.Left, a.Transforms)
+}
+
+// AdapterImports returns the import paths of the functions of the
+// transforms of the model's adapters.
+func (f *RelationalModelDefinition) AdapterImports() []string {
+	var transforms []*TransformDefinition
+	for _, a := range f.Adapters() {
+		switch a := a.(type) {
+		case *MediaTypeAdapterDefinition:
+			transforms = append(transforms, a.Transforms...)
+		case *UserTypeAdapterDefinition:
+			transforms = append(transforms, a.Transforms...)
+		case *PayloadAdapterDefinition:
+			transforms = append(transforms, a.Transforms...)
+		}
+	}
+	seen := make(map[string]bool)
+	var imports []string
+	for _, t := range transforms {
+		if pkg, _ := t.function(); pkg != "" && !seen[pkg] {
+			seen[pkg] = true
+			imports = append(imports, pkg)
+		}
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+// Adapters returns the adapters of the model sorted by name: the media
+// type adapters, the user type adapters and the payload adapters.
+func (f *RelationalModelDefinition) Adapters() []dslengine.Definition {
+	var adapters []dslengine.Definition
+	for _, name := range sortedAdapterNames(f.MediaTypeAdapters) {
+		adapters = append(adapters, f.MediaTypeAdapters[name])
+	}
+	for _, name := range sortedAdapterNames(f.UserTypeAdapters) {
+		adapters = append(adapters, f.UserTypeAdapters[name])
+	}
+	for _, name := range sortedAdapterNames(f.PayloadAdapters) {
+		adapters = append(adapters, f.PayloadAdapters[name])
+	}
+	return adapters
+}
+
+// HasAdapter returns true if the model declares an adapter with the name.
+func (f *RelationalModelDefinition) HasAdapter(name string) bool {
+	_, mt := f.MediaTypeAdapters[name]
+	_, ut := f.UserTypeAdapters[name]
+	_, p := f.PayloadAdapters[name]
+	return mt || ut || p
+}
+
+// sortedAdapterNames returns the sorted keys of a map of adapters.
+func sortedAdapterNames(adapters interface{}) []string {
+	var names []string
+	switch a := adapters.(type) {
+	case map[string]*MediaTypeAdapterDefinition:
+		for name := range a {
+			names = append(names, name)
+		}
+	case map[string]*UserTypeAdapterDefinition:
+		for name := range a {
+			names = append(names, name)
+		}
+	case map[string]*PayloadAdapterDefinition:
+		for name := range a {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// function splits the function of the transform into its import path,
+// empty for the functions of the generated package, and its name
+// qualified by the package name.
+func (t *TransformDefinition) function() (string, string) {
+	i := strings.LastIndex(t.Func, ".")
+	if i < 0 {
+		return "", t.Func
+	}
+	pkg := t.Func[:i]
+	return pkg, path.Base(pkg) + t.Func[i:]
+}
+
+// value returns the expression of the value of the transform, computed
+// from the variable v.
+func (t *TransformDefinition) value(v string) string {
+	if t.Func != "" {
+		_, fn := t.function()
+		return fmt.Sprintf("%s(%s)", fn, v)
+	}
+	return t.Expr
+}
+
+// adapterAssignments returns the code setting the fields of the model m
+// from the user type held by the src variable.
+func adapterAssignments(model *RelationalModelDefinition, ut *design.UserTypeDefinition, transforms []*TransformDefinition) string {
+	code := []string{payloadToModelAssignments(model, ut, "src", "m", false)}
+	for _, t := range transforms {
+		code = append(code, fmt.Sprintf("m.%s = %s", t.Target, t.value("src")))
+	}
+	return strings.Join(code, "\n")
+}
+
+// modelTarget returns the function reporting whether a transform target
+// is a field of the model.
+func modelTarget(model *RelationalModelDefinition) func(string) bool {
+	return func(target string) bool {
+		_, ok := model.RelationalFields[target]
+		return ok
+	}
+}
+
+// validateTransforms reports the adapters whose name isn't a Go
+// identifier and the transforms computing unknown or already computed
+// targets, or computing them with neither an expression nor a function.
+func validateTransforms(a dslengine.Definition, name string, transforms []*TransformDefinition, known func(string) bool) error {
+	verr := new(dslengine.ValidationErrors)
+	if !token.IsIdentifier(name) || !token.IsExported(name) {
+		verr.Add(a, "adapter name %q is not an exported Go identifier", name)
+	}
+	seen := make(map[string]bool)
+	for _, t := range transforms {
+		if !known(t.Target) {
+			verr.Add(a, "unknown transform target %s", t.Target)
+		}
+		if seen[t.Target] {
+			verr.Add(a, "%s transformed twice", t.Target)
+		}
+		seen[t.Target] = true
+		switch {
+		case t.Expr == "" && t.Func == "":
+			verr.Add(a, "transform of %s has neither an expression nor a function", t.Target)
+		case t.Func != "":
+			if !token.IsIdentifier(t.Func[strings.LastIndex(t.Func, ".")+1:]) {
+				verr.Add(a, "invalid function %s in the transform of %s", t.Func, t.Target)
+			}
+		}
+	}
+	if err := verr.AsError(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// adapterContext returns the name of an adapter used in error messages.
+func adapterContext(kind, name string) string {
+	if name != "" {
+		return fmt.Sprintf("%s %#v", kind, name)
+	}
+	return "unnamed " + kind
+}
+
+// adapterError returns a validation error of the adapter.
+func adapterError(a dslengine.Definition, msg string) error {
+	verr := new(dslengine.ValidationErrors)
+	verr.Add(a, msg)
+	return verr
+}
@@ -0,0 +1,123 @@
+package gorma_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Gys/goa/design"
+	"github.com/Gys/gorma"
+)
+
+func TestAdapterAssignments(t *testing.T) {
+	sd := makeStore(gorma.Postgres)
+	user := makeModel(sd, "User")
+	makeField(user, "Email", gorma.String)
+	makeField(user, "FirstName", gorma.String)
+	contact := &design.UserTypeDefinition{
+		TypeName: "Contact",
+		AttributeDefinition: &design.AttributeDefinition{
+			Type: design.Object{
+				"email":     &design.AttributeDefinition{Type: design.String},
+				"full_name": &design.AttributeDefinition{Type: design.String},
+			},
+		},
+	}
+	a := &gorma.UserTypeAdapterDefinition{
+		Name:  "UserFromContact",
+		Left:  contact,
+		Right: user,
+		Transforms: []*gorma.TransformDefinition{
+			{Target: "FirstName", Func: "github.com/acme/names.First"},
+			{Target: "ID", Expr: "42"},
+		},
+	}
+
+	if err := a.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	exp := `if src.Email != nil {
+	m.Email = *src.Email
+}
+m.FirstName = names.First(src)
+m.ID = 42`
+	if code := a.Assignments(); code != exp {
+		t.Errorf("expected\n%s\ngot\n%s", exp, code)
+	}
+	user.UserTypeAdapters[a.Name] = a
+	if imports := user.AdapterImports(); !reflect.DeepEqual(imports, []string{"github.com/acme/names"}) {
+		t.Errorf("unexpected imports %v", imports)
+	}
+}
+
+func TestAdapterValidate(t *testing.T) {
+	sd := makeStore(gorma.Postgres)
+	user := makeModel(sd, "User")
+	a := &gorma.PayloadAdapterDefinition{
+		Name:  "userFromSignup",
+		Left:  &design.UserTypeDefinition{TypeName: "Signup", AttributeDefinition: &design.AttributeDefinition{Type: design.Object{}}},
+		Right: user,
+		Transforms: []*gorma.TransformDefinition{
+			{Target: "Nickname", Expr: `"ann"`},
+			{Target: "ID", Expr: "1"},
+			{Target: "ID", Func: "github.com/acme/ids.New-ID"},
+			{Target: "CreatedAt"},
+		},
+	}
+	err := a.Validate()
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, exp := range []string{
+		`adapter name "userFromSignup" is not an exported Go identifier`,
+		"unknown transform target Nickname",
+		"ID transformed twice",
+		"invalid function github.com/acme/ids.New-ID in the transform of ID",
+		"transform of CreatedAt has neither an expression nor a function",
+	} {
+		if !strings.Contains(err.Error(), exp) {
+			t.Errorf("missing error %q in %s", exp, err)
+		}
+	}
+}
+
+func TestAdapterAssignmentsCompositeKey(t *testing.T) {
+	sd := makeStore(gorma.Postgres)
+	membership := makeModel(sd, "Membership")
+	delete(membership.RelationalFields, "ID")
+	user := makeField(membership, "UserID", gorma.Integer)
+	group := makeField(membership, "GroupID", gorma.Integer)
+	membership.PrimaryKeys = []*gorma.RelationalFieldDefinition{user, group}
+	grant := makeModel(sd, "Grant")
+	makeField(grant, "MembershipID", gorma.BelongsTo)
+	grant.BelongsTo["Membership"] = membership
+	obj := design.Object{
+		"membership_id":      &design.AttributeDefinition{Type: design.Integer},
+		"membership_user_id": &design.AttributeDefinition{Type: design.Integer},
+	}
+
+	ut := &gorma.UserTypeAdapterDefinition{
+		Name:  "GrantFromRequest",
+		Left:  &design.UserTypeDefinition{TypeName: "Request", AttributeDefinition: &design.AttributeDefinition{Type: obj}},
+		Right: grant,
+	}
+	exp := "if src.MembershipUserID != nil {\n\tm.MembershipUserID = *src.MembershipUserID\n}"
+	if code := ut.Assignments(); code != exp {
+		t.Errorf("expected\n%s\ngot\n%s", exp, code)
+	}
+
+	mt := &design.MediaTypeDefinition{
+		UserTypeDefinition: &design.UserTypeDefinition{
+			TypeName:            "GrantMedia",
+			AttributeDefinition: &design.AttributeDefinition{Type: obj},
+		},
+	}
+	mt.Views = map[string]*design.ViewDefinition{
+		"default": {AttributeDefinition: &design.AttributeDefinition{Type: obj}, Name: "default", Parent: mt},
+	}
+	mta := &gorma.MediaTypeAdapterDefinition{Name: "GrantToMedia", Left: mt, Right: grant}
+	exp = "\tgrant.MembershipUserID = &src.MembershipUserID"
+	if code := mta.Assignments("grant"); code != exp {
+		t.Errorf("expected\n%s\ngot\n%s", exp, code)
+	}
+}
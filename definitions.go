@@ -11,6 +11,12 @@ type RelationalStorageType string
 // FieldType is the storage data type for a database field.
 type FieldType string
 
+// KeyGeneratorType is the way new primary keys are generated.
+type KeyGeneratorType string
+
+// FilterOperator is a comparison lists may be filtered with.
+type FilterOperator string
+
 // StorageGroupDefinition is the parent configuration structure for Gorma definitions.
 type StorageGroupDefinition struct {
 	dslengine.Definition
@@ -32,6 +38,7 @@ type RelationalStoreDefinition struct {
 	NoAutoIDFields   bool
 	NoAutoTimestamps bool
 	NoAutoSoftDelete bool
+	Seeds            []*SeedDefinition // seed rows, in declaration order
 }
 
 // RelationalModelDefinition implements the storage of a domain model into a
@@ -39,27 +46,66 @@ type RelationalStoreDefinition struct {
 type RelationalModelDefinition struct {
 	dslengine.Definition
 	*design.UserTypeDefinition
-	DefinitionDSL    func()
-	ModelName        string
-	Description      string
-	GoaType          *design.MediaTypeDefinition
-	Parent           *RelationalStoreDefinition
-	BuiltFrom        map[string]*design.UserTypeDefinition
-	BuildSources     []*BuildSource
-	RenderTo         map[string]*design.MediaTypeDefinition
-	BelongsTo        map[string]*RelationalModelDefinition
-	HasMany          map[string]*RelationalModelDefinition
-	HasOne           map[string]*RelationalModelDefinition
-	ManyToMany       map[string]*ManyToManyDefinition
-	Alias            string // gorm:tablename
-	Cached           bool
-	CacheDuration    int
-	Roler            bool
-	DynamicTableName bool
-	SQLTag           string
-	RelationalFields map[string]*RelationalFieldDefinition
-	PrimaryKeys      []*RelationalFieldDefinition
-	many2many        []string
+	DefinitionDSL     func()
+	ModelName         string
+	Description       string
+	GoaType           *design.MediaTypeDefinition
+	Parent            *RelationalStoreDefinition
+	BuiltFrom         map[string]*design.UserTypeDefinition
+	BuildSources      []*BuildSource
+	RenderTo          map[string]*design.MediaTypeDefinition
+	Renders           map[string]*RenderDefinition
+	BelongsTo         map[string]*RelationalModelDefinition
+	HasMany           map[string]*RelationalModelDefinition
+	HasOne            map[string]*RelationalModelDefinition
+	ManyToMany        map[string]*ManyToManyDefinition
+	Alias             string // gorm:tablename
+	Cached            bool
+	CacheDuration     int
+	Roler             bool
+	DynamicTableName  bool
+	SQLTag            string
+	RelationalFields  map[string]*RelationalFieldDefinition
+	PrimaryKeys       []*RelationalFieldDefinition
+	Indexes           map[string]*IndexDefinition
+	MediaTypeAdapters map[string]*MediaTypeAdapterDefinition
+	UserTypeAdapters  map[string]*UserTypeAdapterDefinition
+	PayloadAdapters   map[string]*PayloadAdapterDefinition
+	many2many         []string
+}
+
+// IndexDefinition represents an index on one or more columns of a
+// model's table.
+type IndexDefinition struct {
+	dslengine.Definition
+	DefinitionDSL func()
+	Parent        *RelationalModelDefinition
+	Name          string
+	Unique        bool
+	Fields        []string        // indexed fields, in index order
+	Descending    map[string]bool // fields sorted in descending order
+	Condition     string          // partial index condition
+}
+
+// SeedDefinition holds rows of reference data the Seed<Store> function
+// upserts into the table of a model of the store.
+type SeedDefinition struct {
+	dslengine.Definition
+	DefinitionDSL func()
+	Parent        *RelationalStoreDefinition
+	ModelName     string
+	Rows          []map[string]interface{} // column values by field name
+}
+
+// RenderDefinition represents the rendering of a model to a media type:
+// the views generated and the relationships they preload, see RendersTo.
+type RenderDefinition struct {
+	dslengine.Definition
+	DefinitionDSL func()
+	Parent        *RelationalModelDefinition
+	MediaType     *design.MediaTypeDefinition
+	Views         []string            // all the views of the media type if empty
+	Preloads      map[string][]string // relationships preloaded by view, "" for all views
 }
 
 // BuildSource stores the BuildsFrom sources
@@ -78,10 +124,8 @@ type MapDefinition struct {
 	RemoteField string
 }
 
-// MediaTypeAdapterDefinition represents the transformation of a
-// Goa media type into a Gorma Model.
-//
-// Unimplemented at this time.
+// MediaTypeAdapterDefinition represents the transformation of a Gorma
+// model into a Goa media type, see MediaTypeAdapter.
 type MediaTypeAdapterDefinition struct {
 	dslengine.Definition
 	DefinitionDSL func()
@@ -89,26 +133,24 @@ type MediaTypeAdapterDefinition struct {
 	Description   string
 	Left          *design.MediaTypeDefinition
 	Right         *RelationalModelDefinition
+	Transforms    []*TransformDefinition
 }
 
 // UserTypeAdapterDefinition represents the transformation of a Goa
-// user type into a Gorma Model.
-//
-// Unimplemented at this time.
+// user type into a Gorma Model, see UserTypeAdapter.
 type UserTypeAdapterDefinition struct {
 	dslengine.Definition
 	DefinitionDSL func()
 	Name          string
 	Description   string
-	Left          *RelationalModelDefinition
+	Left          *design.UserTypeDefinition
 	Right         *RelationalModelDefinition
+	Transforms    []*TransformDefinition
 }
 
 // PayloadAdapterDefinition represents the transformation of a Goa
 // Payload (which is really a UserTypeDefinition)
-// into a Gorma model.
-//
-// Unimplemented at this time.
+// into a Gorma model, see PayloadAdapter.
 type PayloadAdapterDefinition struct {
 	dslengine.Definition
 	DefinitionDSL func()
@@ -116,6 +158,16 @@ type PayloadAdapterDefinition struct {
 	Description   string
 	Left          *design.UserTypeDefinition
 	Right         *RelationalModelDefinition
+	Transforms    []*TransformDefinition
+}
+
+// TransformDefinition is the custom conversion of a field of the model or
+// of an attribute of the media type in an adapter, computed by a Go
+// expression or function.
+type TransformDefinition struct {
+	Target string // model field or media type attribute
+	Expr   string // Go expression of the value
+	Func   string // Go function computing the value, qualified by its import path if external
 }
 
 // RelationalFieldDefinition represents
@@ -133,8 +185,15 @@ type RelationalFieldDefinition struct {
 	Description       string
 	Nullable          bool
 	PrimaryKey        bool
+	Unique            bool
 	Timestamp         bool
-	Size              int // string field size
+	Size              int                             // string field size
+	Default           interface{}                     // column default value
+	Check             string                          // CHECK constraint expression
+	Validation        *dslengine.ValidationDefinition // validations of the payload attribute
+	KeyGenerator      KeyGeneratorType                // primary key generator
+	Sortable          bool                            // may order lists
+	FilterOps         []FilterOperator                // operators filtering lists
 	BelongsTo         string
 	HasOne            string
 	HasMany           string
@@ -165,6 +224,10 @@ type ModelIterator func(m *RelationalModelDefinition) error
 // in a RelationalModel.
 type FieldIterator func(m *RelationalFieldDefinition) error
 
+// IndexIterator is a function that iterates over Indexes
+// in a RelationalModel.
+type IndexIterator func(i *IndexDefinition) error
+
 // BuildSourceIterator is a function that iterates over Fields
 // in a RelationalModel.
 type BuildSourceIterator func(m *BuildSource) error
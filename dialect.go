@@ -0,0 +1,366 @@
+package gorma
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialect returns the storage type of the store the model belongs to.
+func (f *RelationalModelDefinition) Dialect() RelationalStorageType {
+	if f.Parent == nil {
+		return None
+	}
+	return f.Parent.Type
+}
+
+// Dialect returns the storage type of the store the field's model belongs
+// to.
+func (f *RelationalFieldDefinition) Dialect() RelationalStorageType {
+	if f.Parent == nil {
+		return None
+	}
+	return f.Parent.Dialect()
+}
+
+// quoteIdentifier quotes a table, column or index name for the dialect.
+func quoteIdentifier(dialect RelationalStorageType, name string) string {
+	if dialect == MySQL {
+		return "`" + name + "`"
+	}
+	return `"` + name + `"`
+}
+
+// quoteColumns returns the quoted, comma separated column names of the
+// fields.
+func quoteColumns(dialect RelationalStorageType, fields []*RelationalFieldDefinition) string {
+	var cols []string
+	for _, f := range fields {
+		cols = append(cols, quoteIdentifier(dialect, f.ColumnName()))
+	}
+	return strings.Join(cols, ", ")
+}
+
+// sqlTagSettings splits a gorm style `sql` tag into its settings.  Keys are
+// upper cased the same way gorm does it, flags map to their own name.
+func sqlTagSettings(tag string) map[string]string {
+	settings := make(map[string]string)
+	for _, s := range strings.Split(tag, ";") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		kv := strings.SplitN(s, ":", 2)
+		k := strings.ToUpper(strings.TrimSpace(kv[0]))
+		if len(kv) == 2 {
+			settings[k] = strings.TrimSpace(kv[1])
+		} else {
+			settings[k] = k
+		}
+	}
+	return settings
+}
+
+// fieldSize returns the size of a string column.
+func fieldSize(f *RelationalFieldDefinition) int {
+	if f.Size > 0 {
+		return f.Size
+	}
+	if s, ok := sqlTagSettings(f.SQLTag)["SIZE"]; ok {
+		var size int
+		if _, err := fmt.Sscanf(s, "%d", &size); err == nil && size > 0 {
+			return size
+		}
+	}
+	return 255
+}
+
+// sqlDatatype returns the column type of the field for the dialect.
+// An explicit `type` in the field's SQLTag always wins.
+func sqlDatatype(f *RelationalFieldDefinition, dialect RelationalStorageType) string {
+	if t, ok := sqlTagSettings(f.SQLTag)["TYPE"]; ok {
+		return t
+	}
+	switch f.Datatype {
+	case Boolean:
+		return "BOOLEAN"
+	case Integer, AutoInteger:
+		if dialect == MySQL {
+			return "INT"
+		}
+		return "INTEGER"
+	case BigInteger, AutoBigInteger:
+		return "BIGINT"
+	case Decimal:
+		if dialect == MySQL {
+			return "FLOAT"
+		}
+		return "REAL"
+	case BigDecimal:
+		switch dialect {
+		case MySQL:
+			return "DOUBLE"
+		case SQLite3:
+			return "REAL"
+		}
+		return "DOUBLE PRECISION"
+	case String:
+		return fmt.Sprintf("VARCHAR(%d)", fieldSize(f))
+	case Text:
+		return "TEXT"
+	case UUID:
+		if dialect == Postgres {
+			return "UUID"
+		}
+		return "CHAR(36)"
+	case JSON:
+		if dialect == Postgres {
+			return "JSONB"
+		}
+		return "TEXT"
+	case Timestamp, NullableTimestamp:
+		if dialect == Postgres || dialect == None {
+			return "TIMESTAMP WITH TIME ZONE"
+		}
+		return "DATETIME"
+	case BelongsTo, HasOneKey, HasManyKey:
+		if pk := relatedPrimaryKey(f); pk != nil && pk != f {
+			return sqlDatatype(pk, dialect)
+		}
+		return sqlDatatype(&RelationalFieldDefinition{Datatype: Integer}, dialect)
+	}
+	return ""
+}
+
+// isAutoIncrement reports whether the field is the single integer primary
+// key of its model, which the database populates itself.
+func isAutoIncrement(f *RelationalFieldDefinition) bool {
+	if !f.PrimaryKey || f.Parent == nil || len(f.Parent.PrimaryKeyFields()) != 1 {
+		return false
+	}
+	if _, ok := sqlTagSettings(f.SQLTag)["TYPE"]; ok {
+		return false
+	}
+	switch f.Datatype {
+	case Integer, BigInteger, AutoInteger, AutoBigInteger:
+		return f.KeyGenerator == "" || f.KeyGenerator == AutoIncrement
+	}
+	return false
+}
+
+// isGeneratedUUID reports whether the field is a UUID primary key the
+// database generates itself.  Only Postgres has a native generator; the
+// other databases get their keys from the generated Add method.
+func isGeneratedUUID(f *RelationalFieldDefinition) bool {
+	if f.Datatype != UUID || !f.PrimaryKey || f.KeyGenerator != "" || f.Dialect() != Postgres {
+		return false
+	}
+	_, ok := sqlTagSettings(f.SQLTag)["DEFAULT"]
+	return !ok && len(f.Parent.PrimaryKeyFields()) == 1
+}
+
+// sqlTypeTags returns the `sql` tag settings the field needs for gorm to
+// create its column with the right type in the store's dialect.  Fields of
+// models outside of a store, or with an explicit `type` in their SQLTag,
+// get none.
+func sqlTypeTags(f *RelationalFieldDefinition) []string {
+	dialect := f.Dialect()
+	if dialect == None {
+		return nil
+	}
+	if _, ok := sqlTagSettings(f.SQLTag)["TYPE"]; ok {
+		return nil
+	}
+	var tags []string
+	switch f.Datatype {
+	case Text, UUID, JSON:
+		tags = append(tags, "type:"+strings.ToLower(sqlDatatype(f, dialect)))
+	case String:
+		if _, ok := sqlTagSettings(f.SQLTag)["SIZE"]; !ok && f.Size > 0 {
+			tags = append(tags, fmt.Sprintf("size:%d", f.Size))
+		}
+	}
+	if isGeneratedUUID(f) {
+		tags = append(tags, "default:gen_random_uuid()")
+	}
+	return tags
+}
+
+// sqlConstraintTags returns the `sql` tag settings of the field's default
+// value and check constraint.  Only nullable fields get their default in
+// the tag: gorm leaves the fields holding their zero value out of the
+// INSERT of a column with a default, which is nil for a pointer but would
+// be an explicit false, 0 or "" otherwise.
+func sqlConstraintTags(f *RelationalFieldDefinition) []string {
+	var tags []string
+	settings := sqlTagSettings(f.SQLTag)
+	if _, ok := settings["DEFAULT"]; !ok && f.Default != nil && f.Nullable {
+		tags = append(tags, "default:"+sqlLiteral(f.Default, f.Dialect()))
+	}
+	if _, ok := settings["CHECK"]; !ok && f.Check != "" {
+		tags = append(tags, "check:"+f.Check)
+	}
+	return tags
+}
+
+// columnDefinition returns the column clause of a CREATE TABLE statement
+// for the field.
+func columnDefinition(f *RelationalFieldDefinition, dialect RelationalStorageType) string {
+	col := quoteIdentifier(dialect, f.ColumnName()) + " "
+	if isAutoIncrement(f) {
+		big := f.Datatype == BigInteger || f.Datatype == AutoBigInteger
+		switch dialect {
+		case MySQL:
+			return col + sqlDatatype(f, dialect) + " NOT NULL AUTO_INCREMENT"
+		case SQLite3:
+			return col + "INTEGER PRIMARY KEY AUTOINCREMENT"
+		}
+		if big {
+			return col + "BIGSERIAL"
+		}
+		return col + "SERIAL"
+	}
+	def := col + sqlDatatype(f, dialect)
+	if dialect == Postgres && isGeneratedUUID(f) {
+		return def + " NOT NULL DEFAULT gen_random_uuid()"
+	}
+	if f.Nullable {
+		def += " NULL"
+	} else {
+		def += " NOT NULL"
+	}
+	if f.Default != nil {
+		def += " DEFAULT " + sqlLiteral(f.Default, dialect)
+	}
+	if dialect == MySQL && f.Description != "" {
+		def += " COMMENT " + sqlLiteral(f.Description, dialect)
+	}
+	return def
+}
+
+// sqlLiteral returns the SQL literal of a value for the dialect.
+func sqlLiteral(v interface{}, dialect RelationalStorageType) string {
+	switch val := v.(type) {
+	case bool:
+		if dialect == SQLite3 {
+			if val {
+				return "1"
+			}
+			return "0"
+		}
+		if val {
+			return "TRUE"
+		}
+		return "FALSE"
+	case string:
+		if dialect == MySQL {
+			val = strings.Replace(val, "\\", "\\\\", -1)
+		}
+		return "'" + strings.Replace(val, "'", "''", -1) + "'"
+	}
+	return fmt.Sprint(v)
+}
+
+// columnCheck returns the CHECK constraint expression of the field's
+// column: the expression given with the Check DSL and the validations of
+// the payload attribute the field is built from.
+func columnCheck(f *RelationalFieldDefinition, dialect RelationalStorageType) string {
+	var checks []string
+	if f.Check != "" {
+		checks = append(checks, f.Check)
+	}
+	checks = append(checks, validationChecks(f, dialect)...)
+	if len(checks) < 2 {
+		return strings.Join(checks, "")
+	}
+	for i, c := range checks {
+		checks[i] = "(" + c + ")"
+	}
+	return strings.Join(checks, " AND ")
+}
+
+// validationChecks returns the SQL conditions enforcing the validations of
+// the field.  The maximum length of String fields is enforced by the size
+// of the column and SQLite has no regular expressions.
+func validationChecks(f *RelationalFieldDefinition, dialect RelationalStorageType) []string {
+	v := f.Validation
+	if v == nil || f.validationAttribute() == nil {
+		return nil
+	}
+	col := quoteIdentifier(dialect, f.ColumnName())
+	var checks []string
+	if len(v.Values) > 0 {
+		vals := make([]string, len(v.Values))
+		for i, val := range v.Values {
+			vals[i] = sqlLiteral(val, dialect)
+		}
+		checks = append(checks, fmt.Sprintf("%s IN (%s)", col, strings.Join(vals, ", ")))
+	}
+	if v.Minimum != nil {
+		checks = append(checks, fmt.Sprintf("%s >= %s", col, strconv.FormatFloat(*v.Minimum, 'f', -1, 64)))
+	}
+	if v.Maximum != nil {
+		checks = append(checks, fmt.Sprintf("%s <= %s", col, strconv.FormatFloat(*v.Maximum, 'f', -1, 64)))
+	}
+	length := "CHAR_LENGTH"
+	if dialect == SQLite3 {
+		length = "LENGTH"
+	}
+	if v.MinLength != nil && *v.MinLength > 0 {
+		checks = append(checks, fmt.Sprintf("%s(%s) >= %d", length, col, *v.MinLength))
+	}
+	if v.MaxLength != nil && f.Datatype == Text {
+		checks = append(checks, fmt.Sprintf("%s(%s) <= %d", length, col, *v.MaxLength))
+	}
+	if v.Pattern != "" {
+		switch dialect {
+		case MySQL:
+			checks = append(checks, fmt.Sprintf("%s REGEXP %s", col, sqlLiteral(v.Pattern, dialect)))
+		case SQLite3:
+		default:
+			checks = append(checks, fmt.Sprintf("%s ~ %s", col, sqlLiteral(v.Pattern, dialect)))
+		}
+	}
+	return checks
+}
+
+// checkName returns the name of the CHECK constraint of a column.
+func checkName(table, column string) string {
+	return fmt.Sprintf("chk_%s_%s", table, column)
+}
+
+// foreignKeyName returns the name of the foreign key constraint of the
+// columns of the table.
+func foreignKeyName(table string, columns []string) string {
+	return fmt.Sprintf("fk_%s_%s", table, strings.Join(columns, "_"))
+}
+
+// goDefault returns the Go expression of the field's default value, typed
+// as the (non pointer) field type.
+func goDefault(f *RelationalFieldDefinition) string {
+	return goLiteral(f, f.Default)
+}
+
+// goLiteral returns the Go expression of a value of the field, typed as
+// the (non pointer) field type.  Timestamps are RFC 3339 strings.
+func goLiteral(f *RelationalFieldDefinition, v interface{}) string {
+	switch keyDatatype(f) {
+	case UUID:
+		return fmt.Sprintf("uuid.FromStringOrNil(%q)", v)
+	case JSON:
+		if f.Dialect() == Postgres {
+			return fmt.Sprintf("postgres.Jsonb{RawMessage: json.RawMessage(%q)}", v)
+		}
+		return fmt.Sprintf("json.RawMessage(%q)", v)
+	case String, Text:
+		return fmt.Sprintf("%q", v)
+	case Boolean:
+		return fmt.Sprint(v)
+	case Timestamp, NullableTimestamp:
+		t, _ := time.Parse(time.RFC3339Nano, fmt.Sprint(v))
+		t = t.UTC()
+		return fmt.Sprintf("time.Date(%d, %d, %d, %d, %d, %d, %d, time.UTC)", t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond())
+	}
+	return fmt.Sprintf("%s(%v)", goDatatype(f, false), v)
+}
@@ -0,0 +1,150 @@
+package dsl
+
+import (
+	"github.com/Gys/goa/design"
+	"github.com/Gys/goa/dslengine"
+	"github.com/Gys/gorma"
+)
+
+// MediaTypeAdapter declares a named function converting the model into a
+// media type.  The attributes of the default view of the media type named
+// after fields are set from them, the Transform DSL computes the others
+// from the model, held by the src variable.
+//
+//	Model("User", func() {
+//		MediaTypeAdapter("UserToProfile", ProfileMedia, func() {
+//			Transform("full_name", `src.FirstName + " " + src.LastName`)
+//		})
+//	})
+//
+// generates:
+//
+//	func UserToProfile(src *User) *app.Profile
+func MediaTypeAdapter(name string, mt *design.MediaTypeDefinition, dsl func()) {
+	if m, ok := relationalModelDefinition(true); ok {
+		if !adapterName(m, name) {
+			return
+		}
+		if mt == nil {
+			dslengine.ReportError("MediaTypeAdapter %s requires a media type", name)
+			return
+		}
+		m.MediaTypeAdapters[name] = &gorma.MediaTypeAdapterDefinition{
+			DefinitionDSL: dsl,
+			Name:          name,
+			Left:          mt,
+			Right:         m,
+		}
+	}
+}
+
+// UserTypeAdapter declares a named function converting a user type into
+// the model.  The fields named after attributes of the user type, or
+// mapped to them, are set from them, the Transform DSL computes the others
+// from the user type, held by the src variable.
+//
+//	Model("User", func() {
+//		UserTypeAdapter("UserFromContact", ContactType, func() {
+//			TransformFunc("FirstName", "github.com/acme/names.First")
+//			TransformFunc("LastName", "github.com/acme/names.Last")
+//		})
+//	})
+//
+// generates:
+//
+//	func UserFromContact(src *app.Contact) *User
+func UserTypeAdapter(name string, ut *design.UserTypeDefinition, dsl func()) {
+	if m, ok := relationalModelDefinition(true); ok {
+		if !adapterName(m, name) {
+			return
+		}
+		if ut == nil {
+			dslengine.ReportError("UserTypeAdapter %s requires a user type", name)
+			return
+		}
+		m.UserTypeAdapters[name] = &gorma.UserTypeAdapterDefinition{
+			DefinitionDSL: dsl,
+			Name:          name,
+			Left:          ut,
+			Right:         m,
+		}
+	}
+}
+
+// PayloadAdapter declares a named function converting the payload of a
+// resource action into the model, see UserTypeAdapter.  The resource is
+// given by name or definition, like in Payload.
+//
+//	Model("Order", func() {
+//		PayloadAdapter("OrderFromCheckout", "order", "checkout", func() {
+//			Transform("AmountCents", "int(src.Total.Amount * 100)")
+//			Transform("Currency", "src.Total.Currency")
+//		})
+//	})
+func PayloadAdapter(name string, r interface{}, act string, dsl func()) {
+	if m, ok := relationalModelDefinition(true); ok {
+		if !adapterName(m, name) {
+			return
+		}
+		payload := actionPayload(r, act)
+		if payload == nil {
+			return
+		}
+		m.PayloadAdapters[name] = &gorma.PayloadAdapterDefinition{
+			DefinitionDSL: dsl,
+			Name:          name,
+			Left:          payload,
+			Right:         m,
+		}
+	}
+}
+
+// Transform sets a field of the model, or an attribute of the media type in
+// a MediaTypeAdapter, to the value of a Go expression.  The expression
+// refers to the converted value as src and must have the type of the
+// target in the generated code, it overrides the value the adapter copies
+// from an attribute or field of the same name.  The expression can't use
+// other packages, TransformFunc can.
+func Transform(target, expr string) {
+	addTransform(target, &gorma.TransformDefinition{Expr: expr})
+}
+
+// TransformFunc sets a field of the model, or an attribute of the media type
+// in a MediaTypeAdapter, to the result of a Go function called with the
+// converted value.  Functions of other packages are qualified by their
+// import path, as in "github.com/acme/money.Cents", the others are
+// functions written in the package of the models.
+func TransformFunc(target, fn string) {
+	addTransform(target, &gorma.TransformDefinition{Func: fn})
+}
+
+// addTransform adds the transform to the adapter being defined: the
+// targets of the adapters converting into the model are names of its
+// fields.
+func addTransform(target string, t *gorma.TransformDefinition) {
+	switch a := dslengine.CurrentDefinition().(type) {
+	case *gorma.MediaTypeAdapterDefinition:
+		t.Target = target
+		a.Transforms = append(a.Transforms, t)
+	case *gorma.UserTypeAdapterDefinition:
+		t.Target = SanitizeFieldName(target)
+		a.Transforms = append(a.Transforms, t)
+	case *gorma.PayloadAdapterDefinition:
+		t.Target = SanitizeFieldName(target)
+		a.Transforms = append(a.Transforms, t)
+	default:
+		dslengine.IncompatibleDSL()
+	}
+}
+
+// adapterName returns true if no adapter of the models of the store uses
+// the name already: the adapters are functions of the same package.
+func adapterName(m *gorma.RelationalModelDefinition, name string) bool {
+	for _, other := range m.Parent.RelationalModels {
+		if other.HasAdapter(name) {
+			dslengine.ReportError("adapter %s already exists in model %s", name, other.ModelName)
+			return false
+		}
+	}
+	return true
+}
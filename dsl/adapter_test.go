@@ -0,0 +1,133 @@
+package dsl_test
+
+import (
+	"github.com/Gys/gorma"
+	gdsl "github.com/Gys/gorma/dsl"
+
+	. "github.com/Gys/goa/design"
+	. "github.com/Gys/goa/design/apidsl"
+	. "github.com/Gys/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Adapters", func() {
+	var ContactType *UserTypeDefinition
+	var UserMedia *MediaTypeDefinition
+	var adapterdsl func()
+
+	BeforeEach(func() {
+		Reset()
+		ContactType = Type("Contact", func() {
+			Attribute("email", String)
+			Attribute("full_name", String)
+		})
+		UserMedia = MediaType("application/vnd.user+json", func() {
+			Attribute("email", String)
+			Attribute("display_name", String)
+			View("default", func() {
+				Attribute("email")
+				Attribute("display_name")
+			})
+		})
+		Resource("user", func() {
+			Action("create", func() {
+				Routing(POST(""))
+				Payload(ContactType)
+			})
+		})
+		adapterdsl = func() {
+			gdsl.UserTypeAdapter("UserFromContact", ContactType, func() {
+				gdsl.Description("splits the full name")
+				gdsl.TransformFunc("first_name", "github.com/acme/names.First")
+				gdsl.TransformFunc("LastName", "github.com/acme/names.Last")
+			})
+			gdsl.PayloadAdapter("UserFromSignup", "user", "create", func() {
+				gdsl.Transform("FirstName", "*src.FullName")
+			})
+			gdsl.MediaTypeAdapter("UserToCard", UserMedia, func() {
+				gdsl.Transform("display_name", "src.DisplayName()")
+			})
+		}
+	})
+
+	JustBeforeEach(func() {
+		gdsl.StorageGroup("production", func() {
+			gdsl.Store("postgres", gorma.Postgres, func() {
+				gdsl.Model("User", func() {
+					gdsl.Field("Email", gorma.String)
+					gdsl.Field("FirstName", gorma.String)
+					gdsl.Field("LastName", gorma.String)
+					adapterdsl()
+				})
+			})
+		})
+		Run()
+	})
+
+	Context("with valid adapters", func() {
+		It("declares them on the model", func() {
+			Ω(Errors).ShouldNot(HaveOccurred())
+			rm := gorma.GormaDesign.RelationalStores["postgres"].RelationalModels["User"]
+
+			ut := rm.UserTypeAdapters["UserFromContact"]
+			Ω(ut).ShouldNot(BeNil())
+			Ω(ut.Left).Should(Equal(ContactType))
+			Ω(ut.Right).Should(Equal(rm))
+			Ω(ut.Description).Should(Equal("splits the full name"))
+			Ω(ut.Transforms).Should(HaveLen(2))
+			Ω(ut.Transforms[0].Target).Should(Equal("FirstName"))
+			Ω(ut.Transforms[0].Func).Should(Equal("github.com/acme/names.First"))
+
+			p := rm.PayloadAdapters["UserFromSignup"]
+			Ω(p).ShouldNot(BeNil())
+			Ω(p.Left.TypeName).Should(Equal("Contact"))
+			Ω(p.Transforms[0].Expr).Should(Equal("*src.FullName"))
+
+			mt := rm.MediaTypeAdapters["UserToCard"]
+			Ω(mt).ShouldNot(BeNil())
+			Ω(mt.Left).Should(Equal(UserMedia))
+			Ω(mt.Transforms[0].Target).Should(Equal("display_name"))
+		})
+	})
+
+	Context("with an unknown transform target", func() {
+		BeforeEach(func() {
+			adapterdsl = func() {
+				gdsl.UserTypeAdapter("UserFromContact", ContactType, func() {
+					gdsl.Transform("Nickname", "*src.FullName")
+				})
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(Errors).Should(HaveOccurred())
+			Ω(Errors.Error()).Should(ContainSubstring("unknown transform target Nickname"))
+		})
+	})
+
+	Context("with a name used twice", func() {
+		BeforeEach(func() {
+			adapterdsl = func() {
+				gdsl.UserTypeAdapter("UserFromContact", ContactType, func() {})
+				gdsl.MediaTypeAdapter("UserFromContact", UserMedia, func() {})
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with a transform outside of an adapter", func() {
+		BeforeEach(func() {
+			adapterdsl = func() {
+				gdsl.Transform("FirstName", "\"ann\"")
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(Errors).Should(HaveOccurred())
+		})
+	})
+})
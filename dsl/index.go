@@ -0,0 +1,84 @@
+package dsl
+
+import (
+	"strings"
+
+	"github.com/Gys/goa/dslengine"
+	"github.com/Gys/gorma"
+)
+
+// Index creates an index on one or more fields of the model.  Fields are
+// indexed in the order given; append "DESC" to a field name to sort it in
+// descending order.  An optional DSL sets the condition of a partial index
+// with Where.
+//
+//	Model("Order", func() {
+//		Index("idx_orders_user", "UserID", "CreatedAt DESC")
+//		Index("idx_orders_open", "UserID", func() {
+//			Where("shipped_at IS NULL")
+//		})
+//	})
+func Index(name string, args ...interface{}) {
+	index(name, false, args...)
+}
+
+// UniqueIndex creates a unique index on one or more fields of the model.
+// It accepts the same arguments as Index.
+//
+//	Model("Membership", func() {
+//		UniqueIndex("uidx_memberships", "UserID", "GroupID")
+//	})
+func UniqueIndex(name string, args ...interface{}) {
+	index(name, true, args...)
+}
+
+// Where sets the condition of a partial index.  The condition is raw SQL,
+// it may only refer to columns of the model's table.  Partial indexes are
+// supported by Postgres and SQLite.
+func Where(condition string) {
+	if i, ok := indexDefinition(true); ok {
+		i.Condition = condition
+	}
+}
+
+func index(name string, unique bool, args ...interface{}) {
+	if m, ok := relationalModelDefinition(true); ok {
+		if _, ok := m.Indexes[name]; ok {
+			dslengine.ReportError("Index %s already exists", name)
+			return
+		}
+		i := gorma.NewIndexDefinition()
+		i.Name = name
+		i.Unique = unique
+		i.Parent = m
+		for n, arg := range args {
+			switch a := arg.(type) {
+			case string:
+				parts := strings.Fields(a)
+				if len(parts) == 0 || len(parts) > 2 {
+					dslengine.ReportError("invalid index field %q", a)
+					continue
+				}
+				field := SanitizeFieldName(parts[0])
+				if len(parts) == 2 {
+					switch strings.ToUpper(parts[1]) {
+					case "ASC":
+					case "DESC":
+						i.Descending[field] = true
+					default:
+						dslengine.ReportError("invalid sort order %q for index field %s", parts[1], parts[0])
+					}
+				}
+				i.Fields = append(i.Fields, field)
+			case func():
+				if n != len(args)-1 {
+					dslengine.ReportError("the DSL must be the last argument of Index")
+				}
+				i.DefinitionDSL = a
+			default:
+				dslengine.InvalidArgError("field name or DSL", arg)
+			}
+		}
+		m.Indexes[name] = i
+	}
+}
@@ -0,0 +1,116 @@
+package dsl_test
+
+import (
+	"github.com/Gys/gorma"
+	gdsl "github.com/Gys/gorma/dsl"
+
+	. "github.com/Gys/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Index", func() {
+	var sgname, storename, modelname string
+	var storetype gorma.RelationalStorageType
+	var dsl func()
+
+	BeforeEach(func() {
+		Reset()
+		sgname = "production"
+		storename = "postgres"
+		storetype = gorma.Postgres
+		modelname = "Order"
+		dsl = nil
+	})
+
+	JustBeforeEach(func() {
+		gdsl.StorageGroup(sgname, func() {
+			gdsl.Store(storename, storetype, func() {
+				gdsl.Model(modelname, func() {
+					gdsl.Field("UserID", gorma.Integer)
+					gdsl.Field("Email", gorma.String, func() {
+						gdsl.Unique()
+					})
+					gdsl.Field("ShippedAt", gorma.Timestamp)
+					dsl()
+				})
+			})
+		})
+		Run()
+	})
+
+	Context("with composite fields", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				gdsl.Index("idx_orders_user", "user_id", "CreatedAt DESC", func() {
+					gdsl.Where("shipped_at IS NULL")
+				})
+				gdsl.UniqueIndex("uidx_orders_email", "Email", "UserID")
+			}
+		})
+
+		It("creates the indexes", func() {
+			Ω(Errors).ShouldNot(HaveOccurred())
+			rm := gorma.GormaDesign.RelationalStores[storename].RelationalModels[modelname]
+			Ω(rm.Indexes).Should(HaveLen(2))
+
+			i := rm.Indexes["idx_orders_user"]
+			Ω(i.Unique).Should(BeFalse())
+			Ω(i.Fields).Should(Equal([]string{"UserID", "CreatedAt"}))
+			Ω(i.Descending["CreatedAt"]).Should(BeTrue())
+			Ω(i.Condition).Should(Equal("shipped_at IS NULL"))
+
+			Ω(rm.Indexes["uidx_orders_email"].Unique).Should(BeTrue())
+		})
+
+		It("sets the unique flag of the field", func() {
+			rm := gorma.GormaDesign.RelationalStores[storename].RelationalModels[modelname]
+			Ω(rm.RelationalFields["Email"].Unique).Should(BeTrue())
+		})
+
+		It("renders the indexes into the struct tags", func() {
+			rm := gorma.GormaDesign.RelationalStores[storename].RelationalModels[modelname]
+			Ω(rm.RelationalFields["UserID"].Tags()).Should(Equal("`gorm:\"index:idx_orders_user;unique_index:uidx_orders_email\"`"))
+		})
+	})
+
+	Context("with an unknown field", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				gdsl.Index("idx_orders_total", "Total")
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with an invalid sort order", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				gdsl.Index("idx_orders_user", "UserID DOWN")
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with a partial index in MySQL", func() {
+		BeforeEach(func() {
+			storename = "mysql"
+			storetype = gorma.MySQL
+			dsl = func() {
+				gdsl.Index("idx_orders_user", "UserID", func() {
+					gdsl.Where("shipped_at IS NULL")
+				})
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(Errors).Should(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,106 @@
+package dsl_test
+
+import (
+	"github.com/Gys/gorma"
+	gdsl "github.com/Gys/gorma/dsl"
+
+	. "github.com/Gys/goa/design"
+	. "github.com/Gys/goa/design/apidsl"
+	. "github.com/Gys/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MapsFrom and MapsTo", func() {
+	var UserPayload *UserTypeDefinition
+	var UserMedia *MediaTypeDefinition
+	var fielddsl, backupdsl func()
+
+	BeforeEach(func() {
+		Reset()
+		UserPayload = Type("UserPayload", func() {
+			Attribute("email", String, func() {
+				MaxLength(120)
+			})
+		})
+		UserMedia = MediaType("application/vnd.user+json", func() {
+			Attribute("contact_email", String)
+			View("default", func() {
+				Attribute("contact_email")
+			})
+		})
+		Resource("user", func() {
+			Action("create", func() {
+				Routing(POST(""))
+				Payload(UserPayload)
+			})
+		})
+		fielddsl = func() {
+			gdsl.MapsFrom(UserPayload, "email")
+			gdsl.MapsTo(UserMedia, "contact_email")
+		}
+		backupdsl = func() {}
+	})
+
+	JustBeforeEach(func() {
+		gdsl.StorageGroup("production", func() {
+			gdsl.Store("postgres", gorma.Postgres, func() {
+				gdsl.Model("User", func() {
+					gdsl.BuildsFrom(func() {
+						gdsl.Payload("user", "create")
+					})
+					gdsl.RendersTo(UserMedia)
+					gdsl.Field("EmailAddress", gorma.String, func() {
+						fielddsl()
+					})
+					gdsl.Field("BackupEmail", gorma.String, func() {
+						backupdsl()
+					})
+				})
+			})
+		})
+		Run()
+	})
+
+	Context("with valid mappings", func() {
+		It("maps the field to the attributes", func() {
+			Ω(Errors).ShouldNot(HaveOccurred())
+			rm := gorma.GormaDesign.RelationalStores["postgres"].RelationalModels["User"]
+			f := rm.RelationalFields["EmailAddress"]
+			Ω(f.Mappings).Should(HaveKey("UserPayload"))
+			Ω(f.Mappings["UserPayload"].RemoteField).Should(Equal("email"))
+			Ω(f.Mappings).Should(HaveKey(UserMedia.TypeName))
+			Ω(f.Mappings[UserMedia.TypeName].RemoteField).Should(Equal("contact_email"))
+		})
+
+		It("replaces the field built from the attribute", func() {
+			rm := gorma.GormaDesign.RelationalStores["postgres"].RelationalModels["User"]
+			Ω(rm.RelationalFields).ShouldNot(HaveKey("Email"))
+			Ω(rm.RelationalFields["EmailAddress"].Size).Should(Equal(120))
+		})
+	})
+
+	Context("with an unknown attribute", func() {
+		BeforeEach(func() {
+			fielddsl = func() {
+				gdsl.MapsFrom(UserPayload, "mail")
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with an attribute mapped twice", func() {
+		BeforeEach(func() {
+			backupdsl = func() {
+				gdsl.MapsFrom(UserPayload, "email")
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(Errors).Should(HaveOccurred())
+		})
+	})
+})
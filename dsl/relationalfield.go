@@ -74,25 +74,31 @@ func Field(name string, args ...interface{}) {
 
 // MapsFrom establishes a mapping relationship between a source
 // Type field and this model.  The source type must be a UserTypeDefinition "Type"
-// in goa.  These are typically Payloads.
+// in goa.  These are typically Payloads.  The model is built from the
+// attribute instead of the attribute named after the field:
+//
+//	Field("EmailAddress", gorma.String, func() {
+//		MapsFrom(UserPayload, "email")
+//		MapsTo(UserMedia, "contact_email")
+//	})
 func MapsFrom(utd *design.UserTypeDefinition, field string) {
 	if f, ok := relationalFieldDefinition(true); ok {
 		md := gorma.NewMapDefinition()
 		md.RemoteField = field
 		md.RemoteType = utd
-		f.Mappings[utd.TypeName] = md
-
+		f.AddMapping(md)
 	}
 }
 
 // MapsTo establishes a mapping relationship between a field in model and
-// a MediaType in goa.
+// a MediaType in goa.  The field renders to the attribute instead of the
+// attribute named after the field.
 func MapsTo(mtd *design.MediaTypeDefinition, field string) {
 	if f, ok := relationalFieldDefinition(true); ok {
 		md := gorma.NewMapDefinition()
 		md.RemoteField = field
 		md.RemoteType = mtd.UserTypeDefinition
-		f.Mappings[mtd.UserTypeDefinition.TypeName] = md
+		f.AddMapping(md)
 	}
 }
 
@@ -118,15 +124,204 @@ func Nullable() {
 // Valid only for `Integer` datatypes currently
 func PrimaryKey() {
 	if f, ok := relationalFieldDefinition(true); ok {
-		if f.Datatype != gorma.Integer && f.Datatype != gorma.UUID {
-			dslengine.ReportError("Integer and UUID are the only supported Primary Key field types.")
+		switch f.Datatype {
+		case gorma.Integer, gorma.BigInteger, gorma.String, gorma.UUID:
+		default:
+			dslengine.ReportError("Integer, BigInteger, String and UUID are the only supported Primary Key field types.")
 		}
 
 		f.PrimaryKey = true
 		f.Nullable = false
 		f.Description = "primary key"
 		f.Parent.PrimaryKeys = append(f.Parent.PrimaryKeys, f)
+
+		// The automatic ID field gives way to the declared primary keys.
+		if id, ok := f.Parent.RelationalFields["ID"]; ok && id != f && id.PrimaryKey && !isPrimaryKey(f.Parent, id) {
+			delete(f.Parent.RelationalFields, "ID")
+		}
+	}
+}
+
+// isPrimaryKey returns true if the field was declared with PrimaryKey.
+func isPrimaryKey(m *gorma.RelationalModelDefinition, f *gorma.RelationalFieldDefinition) bool {
+	for _, pk := range m.PrimaryKeys {
+		if pk == f {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyGenerator sets the way the generated Add method gets the value of a new
+// primary key: gorma.AutoIncrement leaves it to the database (Integer and
+// BigInteger), gorma.UUIDv4 and gorma.UUIDv7 generate UUIDs (UUID and
+// String), gorma.ULID generates ULIDs (String) and gorma.KeyFunc calls the
+// function the application assigns to the generated <Model><Field>Generator
+// variable.  Keys already set are kept.  Without KeyGenerator single
+// Integer keys are auto incremented, UUID keys get random UUIDs and String
+// keys are natural keys the application sets.
+//
+//	Field("Code", gorma.String, func() {
+//		PrimaryKey()
+//		KeyGenerator(gorma.ULID)
+//	})
+func KeyGenerator(gen gorma.KeyGeneratorType) {
+	if f, ok := relationalFieldDefinition(true); ok {
+		var valid bool
+		switch gen {
+		case gorma.AutoIncrement:
+			valid = f.Datatype == gorma.Integer || f.Datatype == gorma.BigInteger
+		case gorma.UUIDv4, gorma.UUIDv7:
+			valid = f.Datatype == gorma.UUID || f.Datatype == gorma.String
+		case gorma.ULID:
+			valid = f.Datatype == gorma.String
+		case gorma.KeyFunc:
+			valid = true
+		default:
+			dslengine.ReportError("unknown key generator %q", gen)
+			return
+		}
+		if !valid {
+			dslengine.ReportError("key generator %q can't generate %s keys", gen, f.Datatype)
+			return
+		}
+		f.KeyGenerator = gen
+		if f.Datatype == gorma.String && f.Size == 0 {
+			switch gen {
+			case gorma.UUIDv4, gorma.UUIDv7:
+				f.Size = 36
+			case gorma.ULID:
+				f.Size = 26
+			}
+		}
+	}
+}
+
+// Sortable allows lists of the model to be ordered by the field, see the
+// OrderBy list option.  Once a field of a model is Sortable, lists of the
+// model may only be ordered by its Sortable fields and primary keys.
+// Nullable fields can't order lists.
+func Sortable() {
+	if f, ok := relationalFieldDefinition(true); ok {
+		switch f.Datatype {
+		case gorma.JSON, gorma.Text, gorma.NullableTimestamp:
+			dslengine.ReportError("%s field %s can't be sortable", f.Datatype, f.FieldName)
+			return
+		}
+		f.Sortable = true
+	}
+}
+
+// Filterable allows lists of the model to be filtered on the field with the
+// given operators, gorma.FilterEq when none is given.  The generated
+// <Model>Filter struct gets a field per operator, named after the field and
+// the operator: Name, NameNe, NameLt, NameGt, NameIn, NameLike and
+// NameIsNull.  Once a field of a model is Filterable, lists of the model may
+// only be filtered on its Filterable fields.
+//
+//	Field("Name", gorma.String, func() {
+//		Filterable(gorma.FilterEq, gorma.FilterLike)
+//	})
+func Filterable(ops ...gorma.FilterOperator) {
+	if f, ok := relationalFieldDefinition(true); ok {
+		switch f.Datatype {
+		case gorma.JSON:
+			dslengine.ReportError("%s field %s can't be filterable", f.Datatype, f.FieldName)
+			return
+		}
+		if len(ops) == 0 {
+			ops = []gorma.FilterOperator{gorma.FilterEq}
+		}
+		for _, op := range ops {
+			switch op {
+			case gorma.FilterEq, gorma.FilterNe, gorma.FilterLt, gorma.FilterGt, gorma.FilterIn, gorma.FilterIsNull:
+			case gorma.FilterLike:
+				if f.Datatype != gorma.String && f.Datatype != gorma.Text {
+					dslengine.ReportError("%s field %s can't be filtered with %q", f.Datatype, f.FieldName, op)
+					return
+				}
+			default:
+				dslengine.ReportError("unknown filter operator %q", op)
+				return
+			}
+			if !hasFilterOp(f, op) {
+				f.FilterOps = append(f.FilterOps, op)
+			}
+		}
+	}
+}
+
+// hasFilterOp returns true if the field is already filterable with op.
+func hasFilterOp(f *gorma.RelationalFieldDefinition, op gorma.FilterOperator) bool {
+	for _, o := range f.FilterOps {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// Unique adds a unique index on the field's column.  Use UniqueIndex in
+// the model to make a combination of fields unique.
+func Unique() {
+	if f, ok := relationalFieldDefinition(true); ok {
+		f.Unique = true
+	}
+}
+
+// Default sets the default value of the field's column.  The value must
+// match the field type: a bool for Boolean fields, an integer for integer
+// fields, a number for decimal fields and a string for String, Text, UUID
+// and JSON fields.  Models built from a payload get the default when the
+// payload omits the field.
+//
+//	Field("Status", gorma.String, func() {
+//		Default("pending")
+//	})
+func Default(value interface{}) {
+	if f, ok := relationalFieldDefinition(true); ok {
+		if !validDefault(f.Datatype, value) {
+			dslengine.ReportError("invalid default value %#v for %s field %s", value, f.Datatype, f.FieldName)
+			return
+		}
+		if s, ok := value.(string); ok && strings.ContainsAny(s, "`;") {
+			dslengine.ReportError("default value of field %s may not contain backticks or semicolons", f.FieldName)
+			return
+		}
+		f.Default = value
+	}
+}
+
+// Check adds a CHECK constraint on the field's column.  The expression is
+// raw SQL.
+//
+//	Field("Quantity", gorma.Integer, func() {
+//		Check("quantity > 0")
+//	})
+func Check(expr string) {
+	if f, ok := relationalFieldDefinition(true); ok {
+		if strings.ContainsAny(expr, "`;") {
+			dslengine.ReportError("check expression of field %s may not contain backticks or semicolons", f.FieldName)
+			return
+		}
+		f.Check = expr
+	}
+}
+
+// validDefault returns true if the value can be the default of a field of
+// the given type.
+func validDefault(t gorma.FieldType, value interface{}) bool {
+	switch value.(type) {
+	case bool:
+		return t == gorma.Boolean
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return t == gorma.Integer || t == gorma.BigInteger || t == gorma.Decimal || t == gorma.BigDecimal
+	case float32, float64:
+		return t == gorma.Decimal || t == gorma.BigDecimal
+	case string:
+		return t == gorma.String || t == gorma.Text || t == gorma.UUID || t == gorma.JSON
 	}
+	return false
 }
 
 // SanitizeFieldName is exported for testing purposes
@@ -198,10 +198,35 @@ var _ = Describe("RelationalField", func() {
 				Ω(rm.RelationalFields["ID"].PrimaryKey).Should(Equal(true))
 			})
 		})
-		Context("won't set Primary Key flags for string", func() {
+		Context("replaces the automatic ID field with composite keys", func() {
+			JustBeforeEach(func() {
+				Reset()
+				gdsl.StorageGroup(sgname, func() {
+					gdsl.Store(storename, gorma.MySQL, func() {
+						gdsl.Model(modelname, func() {
+							gdsl.Field("UserID", gorma.Integer, func() {
+								gdsl.PrimaryKey()
+							})
+							gdsl.Field("GroupID", gorma.Integer, func() {
+								gdsl.PrimaryKey()
+							})
+						})
+					})
+				})
+				Run()
+			})
+			It("drops the ID field", func() {
+				rm := gorma.GormaDesign.RelationalStores[storename].RelationalModels[modelname]
+				Ω(Errors).ShouldNot(HaveOccurred())
+				Ω(rm.RelationalFields).ShouldNot(HaveKey("ID"))
+				Ω(rm.PrimaryKeys).Should(HaveLen(2))
+			})
+		})
+		Context("won't set Primary Key flags for boolean", func() {
 
 			BeforeEach(func() {
 				name = "random"
+				ft = gorma.Boolean
 				dsl = func() {
 					gdsl.PrimaryKey()
 				}
@@ -231,3 +256,255 @@ var _ = Describe("RelationalField", func() {
 	})
 
 })
+
+var _ = Describe("RelationalField defaults and checks", func() {
+	var ft gorma.FieldType
+	var dsl func()
+
+	BeforeEach(func() {
+		Reset()
+		ft = gorma.String
+		dsl = nil
+	})
+
+	JustBeforeEach(func() {
+		gdsl.StorageGroup("production", func() {
+			gdsl.Store("postgres", gorma.Postgres, func() {
+				gdsl.Model("Order", func() {
+					gdsl.Field("Status", ft, dsl)
+				})
+			})
+		})
+		Run()
+	})
+
+	Context("with a default value and a check", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				gdsl.Default("pending")
+				gdsl.Check("status <> ''")
+			}
+		})
+
+		It("sets the default value and the check", func() {
+			Ω(Errors).ShouldNot(HaveOccurred())
+			f := gorma.GormaDesign.RelationalStores["postgres"].RelationalModels["Order"].RelationalFields["Status"]
+			Ω(f.Default).Should(Equal("pending"))
+			Ω(f.Check).Should(Equal("status <> ''"))
+			Ω(f.Tags()).Should(Equal("`sql:\"check:status <> ''\"`"))
+		})
+	})
+
+	Context("with a default value on a nullable field", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				gdsl.Nullable()
+				gdsl.Default("pending")
+			}
+		})
+
+		It("adds the default value to the tags", func() {
+			Ω(Errors).ShouldNot(HaveOccurred())
+			f := gorma.GormaDesign.RelationalStores["postgres"].RelationalModels["Order"].RelationalFields["Status"]
+			Ω(f.Tags()).Should(Equal("`sql:\"default:'pending'\"`"))
+		})
+	})
+
+	Context("with a default value of the wrong type", func() {
+		BeforeEach(func() {
+			ft = gorma.Integer
+			dsl = func() {
+				gdsl.Default("pending")
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with a semicolon in the check", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				gdsl.Check("status <> ''; DROP TABLE orders")
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(Errors).Should(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("RelationalField key generators", func() {
+	var ft gorma.FieldType
+	var dsl func()
+
+	BeforeEach(func() {
+		Reset()
+		ft = gorma.String
+		dsl = nil
+	})
+
+	JustBeforeEach(func() {
+		gdsl.StorageGroup("production", func() {
+			gdsl.Store("postgres", gorma.Postgres, func() {
+				gdsl.Model("Country", func() {
+					gdsl.Field("Code", ft, dsl)
+				})
+			})
+		})
+		Run()
+	})
+
+	Context("with a natural string key", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				gdsl.PrimaryKey()
+			}
+		})
+
+		It("sets the primary key without generator", func() {
+			Ω(Errors).ShouldNot(HaveOccurred())
+			rm := gorma.GormaDesign.RelationalStores["postgres"].RelationalModels["Country"]
+			Ω(rm.RelationalFields).ShouldNot(HaveKey("ID"))
+			Ω(rm.RelationalFields["Code"].PrimaryKey).Should(BeTrue())
+			Ω(rm.RelationalFields["Code"].KeyGenerator).Should(BeEmpty())
+		})
+	})
+
+	Context("with a ULID key", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				gdsl.PrimaryKey()
+				gdsl.KeyGenerator(gorma.ULID)
+			}
+		})
+
+		It("sets the key generator", func() {
+			Ω(Errors).ShouldNot(HaveOccurred())
+			f := gorma.GormaDesign.RelationalStores["postgres"].RelationalModels["Country"].RelationalFields["Code"]
+			Ω(f.KeyGenerator).Should(Equal(gorma.ULID))
+			Ω(f.Size).Should(Equal(26))
+		})
+	})
+
+	Context("with a generator not matching the field type", func() {
+		BeforeEach(func() {
+			ft = gorma.UUID
+			dsl = func() {
+				gdsl.PrimaryKey()
+				gdsl.KeyGenerator(gorma.AutoIncrement)
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with an unknown generator", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				gdsl.PrimaryKey()
+				gdsl.KeyGenerator("uuidv1")
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(Errors).Should(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("RelationalField list options", func() {
+	var ft gorma.FieldType
+	var dsl func()
+
+	BeforeEach(func() {
+		Reset()
+		ft = gorma.String
+		dsl = nil
+	})
+
+	JustBeforeEach(func() {
+		gdsl.StorageGroup("production", func() {
+			gdsl.Store("postgres", gorma.Postgres, func() {
+				gdsl.Model("User", func() {
+					gdsl.Field("Name", ft, dsl)
+				})
+			})
+		})
+		Run()
+	})
+
+	Context("with a sortable and filterable field", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				gdsl.Sortable()
+				gdsl.Filterable(gorma.FilterEq, gorma.FilterLike, gorma.FilterEq)
+			}
+		})
+
+		It("records the sort and filter operators", func() {
+			Ω(Errors).ShouldNot(HaveOccurred())
+			f := gorma.GormaDesign.RelationalStores["postgres"].RelationalModels["User"].RelationalFields["Name"]
+			Ω(f.Sortable).Should(BeTrue())
+			Ω(f.FilterOps).Should(Equal([]gorma.FilterOperator{gorma.FilterEq, gorma.FilterLike}))
+		})
+	})
+
+	Context("with a filterable field and no operators", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				gdsl.Filterable()
+			}
+		})
+
+		It("filters on equality", func() {
+			Ω(Errors).ShouldNot(HaveOccurred())
+			f := gorma.GormaDesign.RelationalStores["postgres"].RelationalModels["User"].RelationalFields["Name"]
+			Ω(f.FilterOps).Should(Equal([]gorma.FilterOperator{gorma.FilterEq}))
+		})
+	})
+
+	Context("with a like filter on an integer field", func() {
+		BeforeEach(func() {
+			ft = gorma.Integer
+			dsl = func() {
+				gdsl.Filterable(gorma.FilterLike)
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with a sortable nullable field", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				gdsl.Nullable()
+				gdsl.Sortable()
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with a sortable field made nullable afterwards", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				gdsl.Sortable()
+				gdsl.Nullable()
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(Errors).Should(HaveOccurred())
+			Ω(Errors.Error()).Should(ContainSubstring("nullable field Name can't be sortable"))
+		})
+	})
+})
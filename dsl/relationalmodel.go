@@ -86,37 +86,98 @@ func Model(name string, dsl func()) {
 // will be generated to convert to/from the model.
 //
 // Usage: RendersTo(MediaType)
-func RendersTo(rt interface{}) {
+//
+// The List, One and To functions are generated for every view of the media
+// type, an optional DSL selects the views and the relationships they
+// preload:
+//
+//	RendersTo(BottleMedia, func() {
+//		Views("default", "tiny")
+//		// preloaded by all the views
+//		Preload("Owner")
+//		// preloaded by the default view only
+//		Preload("Reviews", "default")
+//	})
+func RendersTo(rt interface{}, dsl ...func()) {
 	if m, ok := relationalModelDefinition(false); ok {
 		mts, ok := rt.(*design.MediaTypeDefinition)
 		if ok {
 			m.RenderTo[mts.TypeName] = mts
+			r := &gorma.RenderDefinition{
+				Parent:    m,
+				MediaType: mts,
+				Preloads:  make(map[string][]string),
+			}
+			if len(dsl) > 0 {
+				r.DefinitionDSL = dsl[0]
+			}
+			m.Renders[mts.TypeName] = r
 		}
 
 	}
 }
 
+// Views selects the views of the media type generated in RendersTo, all of
+// them by default.
+func Views(names ...string) {
+	if r, ok := renderDefinition(true); ok {
+		r.Views = append(r.Views, names...)
+	}
+}
+
+// Preload has the List and One functions of the views of RendersTo preload
+// a relationship of the model, given by its name in the model struct: the
+// parent of BelongsTo, the children of HasOne, HasMany or ManyToMany.  The
+// relationship is preloaded by the given views, by all the rendered views
+// when none is given.
+func Preload(relationship string, views ...string) {
+	if r, ok := renderDefinition(true); ok {
+		if len(views) == 0 {
+			views = []string{""}
+		}
+		for _, view := range views {
+			r.Preloads[view] = append(r.Preloads[view], relationship)
+		}
+	}
+}
+
 // BuildsFrom informs Gorma that this model will be populated
 // from a Goa UserType.  Conversion functions
 // will be generated to convert from the payload to the model.
 //
 // Usage:  BuildsFrom(YourType)
 //
+// The source may be a user type, a media type, the name of either, or a
+// DSL calling Payload for the payloads of actions:
+//
+//	BuildsFrom(UserPayload)
+//	BuildsFrom("UserPayload")
+//	BuildsFrom(func() {
+//		Payload("user", "create")
+//	})
+//
+// Each source generates <Model>From<Type> and UpdateFrom<Type>.
+//
 // Fields not in `YourType` that you want in your model must be
 // added explicitly with the `Field` DSL.
-func BuildsFrom(dsl func()) {
+func BuildsFrom(source interface{}) {
 	if m, ok := relationalModelDefinition(false); ok {
-		/*		mts, ok := bf.(*design.UserTypeDefinition)
-				if ok {
-					m.BuiltFrom[mts.TypeName] = mts
-				} else if mts, ok := bf.(*design.MediaTypeDefinition); ok {
-					m.BuiltFrom[mts.TypeName] = mts.UserTypeDefinition
-				}
-				m.PopulateFromModeledType()
-		*/
 		bf := gorma.NewBuildSource()
-		bf.DefinitionDSL = dsl
 		bf.Parent = m
+		switch s := source.(type) {
+		case func():
+			bf.DefinitionDSL = s
+		case *design.UserTypeDefinition, *design.MediaTypeDefinition, string:
+			bf.DefinitionDSL = func() {
+				if ut := userType(s); ut != nil {
+					bf.BuildSourceName = ut.TypeName
+					buildFrom(bf, ut)
+				}
+			}
+		default:
+			dslengine.ReportError("BuildsFrom requires a user type, a media type, a type name or a DSL")
+			return
+		}
 		m.BuildSources = append(m.BuildSources, bf)
 	}
 
@@ -128,32 +189,80 @@ func BuildsFrom(dsl func()) {
 // the Model.
 func Payload(r interface{}, act string) {
 	if bs, ok := buildSourceDefinition(true); ok {
-		var res *design.ResourceDefinition
-		var resName string
-		if n, ok := r.(string); ok {
-			res = design.Design.Resources[n]
-			resName = n
-		} else {
-			res, _ = r.(*design.ResourceDefinition)
-		}
-		if res == nil {
-			dslengine.ReportError("There is no resource %q", resName)
-			return
-		}
-		a, ok := res.Actions[act]
-		if !ok {
-			dslengine.ReportError("There is no action")
+		payload := actionPayload(r, act)
+		if payload == nil {
 			return
 		}
-		payload := a.Payload
 
 		// Set UTD in BuildsFrom parent context
 
-		bs.Parent.BuiltFrom[payload.TypeName] = payload
-		bs.Parent.PopulateFromModeledType()
+		buildFrom(bs, payload)
 	}
 }
 
+// buildFrom adds the type to the types the model of the build source is
+// built from and creates the fields of its attributes.
+func buildFrom(bs *gorma.BuildSource, ut *design.UserTypeDefinition) {
+	bs.Parent.BuiltFrom[ut.TypeName] = ut
+	bs.Parent.PopulateFromModeledType()
+}
+
+// userType returns the user type of a user type or media type definition,
+// or of the user type or media type with the name.  It reports an error
+// and returns nil if there is no such type.
+func userType(t interface{}) *design.UserTypeDefinition {
+	name := ""
+	switch t := t.(type) {
+	case *design.UserTypeDefinition:
+		if t != nil {
+			return t
+		}
+	case *design.MediaTypeDefinition:
+		if t != nil {
+			return t.UserTypeDefinition
+		}
+	case string:
+		if ut, ok := design.Design.Types[t]; ok {
+			return ut
+		}
+		for _, mt := range design.Design.MediaTypes {
+			if mt.TypeName == t {
+				return mt.UserTypeDefinition
+			}
+		}
+		name = t
+	}
+	dslengine.ReportError("There is no user type or media type %q", name)
+	return nil
+}
+
+// actionPayload returns the payload of the action of the resource, given
+// by name or definition.  It reports an error and returns nil if there is
+// no such action or if it has no payload.
+func actionPayload(r interface{}, act string) *design.UserTypeDefinition {
+	var res *design.ResourceDefinition
+	var resName string
+	if n, ok := r.(string); ok {
+		res = design.Design.Resources[n]
+		resName = n
+	} else {
+		res, _ = r.(*design.ResourceDefinition)
+	}
+	if res == nil {
+		dslengine.ReportError("There is no resource %q", resName)
+		return nil
+	}
+	a, ok := res.Actions[act]
+	if !ok {
+		dslengine.ReportError("There is no action")
+		return nil
+	}
+	if a.Payload == nil {
+		dslengine.ReportError("Action %s has no payload", act)
+	}
+	return a.Payload
+}
+
 // BelongsTo signifies a relationship between this model and a
 // Parent.  The Parent has the child, and the Child belongs
 // to the Parent.
@@ -167,7 +276,11 @@ func BelongsTo(parent string) {
 		idfield.Parent = r
 		idfield.Datatype = gorma.BelongsTo
 		idfield.DatabaseFieldName = SanitizeDBFieldName(codegen.Goify(inflect.Singularize(parent), true) + "ID")
-		r.RelationalFields[idfield.FieldName] = idfield
+		// a key declared with Field already, like a primary key of a
+		// join model, keeps its definition
+		if _, ok := r.RelationalFields[idfield.FieldName]; !ok {
+			r.RelationalFields[idfield.FieldName] = idfield
+		}
 		bt, ok := r.Parent.RelationalModels[codegen.Goify(inflect.Singularize(parent), true)]
 		if ok {
 			r.BelongsTo[bt.ModelName] = bt
@@ -365,6 +478,7 @@ func Roler() {
 			field := gorma.NewRelationalFieldDefinition()
 			field.FieldName = "Role"
 			field.Datatype = gorma.String
+			field.Parent = r
 			r.RelationalFields["Role"] = field
 		}
 	}
@@ -685,3 +685,210 @@ var _ = Describe("RelationalModel with auto fields unset", func() {
 		})
 	})
 })
+
+var _ = Describe("BuildsFrom", func() {
+	var ContactType *UserTypeDefinition
+	var ContactMedia *MediaTypeDefinition
+	var source interface{}
+
+	BeforeEach(func() {
+		Reset()
+		ContactType = Type("Contact", func() {
+			Attribute("email", String, func() {
+				MaxLength(80)
+			})
+		})
+		ContactMedia = MediaType("application/vnd.contact+json", func() {
+			TypeName("ContactMedia")
+			Attribute("nickname", String)
+			View("default", func() {
+				Attribute("nickname")
+			})
+		})
+	})
+
+	JustBeforeEach(func() {
+		gdsl.StorageGroup("production", func() {
+			gdsl.Store("mysql", gorma.MySQL, func() {
+				gdsl.Model("User", func() {
+					gdsl.BuildsFrom(source)
+				})
+			})
+		})
+		Run()
+	})
+
+	user := func() *gorma.RelationalModelDefinition {
+		return gorma.GormaDesign.RelationalStores["mysql"].RelationalModels["User"]
+	}
+
+	Context("with a user type", func() {
+		BeforeEach(func() {
+			source = ContactType
+		})
+
+		It("builds the model from the type", func() {
+			Ω(Errors).ShouldNot(HaveOccurred())
+			Ω(user().BuiltFrom).Should(HaveKeyWithValue("Contact", ContactType))
+			Ω(user().RelationalFields).Should(HaveKey("Email"))
+			Ω(user().RelationalFields["Email"].Size).Should(Equal(80))
+		})
+	})
+
+	Context("with a media type", func() {
+		BeforeEach(func() {
+			source = ContactMedia
+		})
+
+		It("builds the model from the type", func() {
+			Ω(Errors).ShouldNot(HaveOccurred())
+			Ω(user().BuiltFrom).Should(HaveKeyWithValue("ContactMedia", ContactMedia.UserTypeDefinition))
+			Ω(user().RelationalFields).Should(HaveKey("Nickname"))
+		})
+	})
+
+	Context("with the name of a type", func() {
+		BeforeEach(func() {
+			source = "ContactMedia"
+		})
+
+		It("builds the model from the type", func() {
+			Ω(Errors).ShouldNot(HaveOccurred())
+			Ω(user().BuiltFrom).Should(HaveKey("ContactMedia"))
+		})
+	})
+
+	Context("with an unknown type name", func() {
+		BeforeEach(func() {
+			source = "Contacts"
+		})
+
+		It("reports an error", func() {
+			Ω(Errors).Should(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("RendersTo", func() {
+	var UserMedia *MediaTypeDefinition
+	var renderdsl func()
+
+	BeforeEach(func() {
+		Reset()
+		UserMedia = MediaType("application/vnd.user+json", func() {
+			TypeName("UserMedia")
+			Attribute("id", Integer)
+			Attribute("email", String)
+			View("default", func() {
+				Attribute("id")
+				Attribute("email")
+			})
+			View("tiny", func() {
+				Attribute("id")
+			})
+		})
+		renderdsl = func() {
+			gdsl.Views("tiny")
+			gdsl.Preload("Orders")
+			gdsl.Preload("Team", "tiny")
+		}
+	})
+
+	JustBeforeEach(func() {
+		gdsl.StorageGroup("production", func() {
+			gdsl.Store("mysql", gorma.MySQL, func() {
+				gdsl.Model("Team", func() {})
+				gdsl.Model("Order", func() {})
+				gdsl.Model("User", func() {
+					gdsl.RendersTo(UserMedia, renderdsl)
+					gdsl.BelongsTo("Team")
+					gdsl.HasMany("Orders", "Order")
+				})
+			})
+		})
+		Run()
+	})
+
+	user := func() *gorma.RelationalModelDefinition {
+		return gorma.GormaDesign.RelationalStores["mysql"].RelationalModels["User"]
+	}
+
+	Context("with views and preloads", func() {
+		It("renders the views selected", func() {
+			Ω(Errors).ShouldNot(HaveOccurred())
+			Ω(user().RenderTo).Should(HaveKey("UserMedia"))
+			Ω(user().RenderedViews("UserMedia")).Should(HaveLen(1))
+			Ω(user().RenderedViews("UserMedia")).Should(HaveKey("tiny"))
+			Ω(user().ViewPreloads("UserMedia", "tiny")).Should(Equal([]string{"Orders", "Team"}))
+		})
+	})
+
+	Context("with an unknown view", func() {
+		BeforeEach(func() {
+			renderdsl = func() {
+				gdsl.Views("full")
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(Errors).Should(HaveOccurred())
+			Ω(Errors.Error()).Should(ContainSubstring("media type UserMedia has no view full"))
+		})
+	})
+
+	Context("with an unknown relationship", func() {
+		BeforeEach(func() {
+			renderdsl = func() {
+				gdsl.Preload("Owner")
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(Errors).Should(HaveOccurred())
+			Ω(Errors.Error()).Should(ContainSubstring("model User has no relationship Owner to preload"))
+		})
+	})
+
+	Context("with a preload of a view not rendered", func() {
+		BeforeEach(func() {
+			renderdsl = func() {
+				gdsl.Views("tiny")
+				gdsl.Preload("Team", "default")
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(Errors).Should(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("BelongsTo a primary key", func() {
+	BeforeEach(func() {
+		Reset()
+		gdsl.StorageGroup("production", func() {
+			gdsl.Store("postgres", gorma.Postgres, func() {
+				gdsl.Model("User", func() {})
+				gdsl.Model("Membership", func() {
+					gdsl.Field("UserID", gorma.Integer, func() {
+						gdsl.PrimaryKey()
+					})
+					gdsl.Field("GroupID", gorma.Integer, func() {
+						gdsl.PrimaryKey()
+					})
+					gdsl.BelongsTo("User")
+				})
+			})
+		})
+		Run()
+	})
+
+	It("keeps the key declared with Field", func() {
+		Ω(Errors).ShouldNot(HaveOccurred())
+		m := gorma.GormaDesign.RelationalStores["postgres"].RelationalModels["Membership"]
+		Ω(m.BelongsTo).Should(HaveKey("User"))
+		Ω(m.RelationalFields["UserID"].PrimaryKey).Should(BeTrue())
+		Ω(m.PrimaryKeyFields()).Should(HaveLen(2))
+		Ω(m.OneAttributes()).Should(Equal("groupID int, userID int"))
+	})
+})
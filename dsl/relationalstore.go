@@ -5,9 +5,10 @@ import (
 	"github.com/Gys/gorma"
 )
 
-// Store represents a database.  Gorma lets you specify
-// a database type, but it's currently not used for any generation
-// logic.
+// Store represents a database.  The database type selects the SQL
+// dialect of the migrations and of the models Gorma generates for the
+// store: column types in the struct tags, quoting of identifiers in
+// queries and the generation of UUID primary keys.
 func Store(name string, storeType gorma.RelationalStorageType, dsl func()) {
 	if name == "" || len(name) == 0 {
 		dslengine.ReportError("Relational Store requires a name.")
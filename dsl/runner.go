@@ -70,3 +70,33 @@ func attributeDefinition(failIfNotSD bool) (*design.AttributeDefinition, bool) {
 	}
 	return a, ok
 }
+
+// indexDefinition returns true and current context if it is an IndexDefinition
+// nil and false otherwise.
+func indexDefinition(failIfNotSD bool) (*gorma.IndexDefinition, bool) {
+	a, ok := dslengine.CurrentDefinition().(*gorma.IndexDefinition)
+	if !ok && failIfNotSD {
+		dslengine.IncompatibleDSL()
+	}
+	return a, ok
+}
+
+// seedDefinition returns true and current context if it is an SeedDefinition
+// nil and false otherwise.
+func seedDefinition(failIfNotSD bool) (*gorma.SeedDefinition, bool) {
+	a, ok := dslengine.CurrentDefinition().(*gorma.SeedDefinition)
+	if !ok && failIfNotSD {
+		dslengine.IncompatibleDSL()
+	}
+	return a, ok
+}
+
+// renderDefinition returns true and current context if it is an RenderDefinition
+// nil and false otherwise.
+func renderDefinition(failIfNotSD bool) (*gorma.RenderDefinition, bool) {
+	a, ok := dslengine.CurrentDefinition().(*gorma.RenderDefinition)
+	if !ok && failIfNotSD {
+		dslengine.IncompatibleDSL()
+	}
+	return a, ok
+}
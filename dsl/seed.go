@@ -0,0 +1,63 @@
+package dsl
+
+import (
+	"github.com/Gys/goa/dslengine"
+	"github.com/Gys/gorma"
+)
+
+// Seed declares rows of reference data of a model: countries, roles,
+// lookup tables.  The Seed<Store> function generated for the store upserts
+// them, parents first.  Seed may be used in a Store or in a Model, the
+// model name may be left empty in the latter to seed the model itself.
+//
+//	Store("postgres", gorma.Postgres, func() {
+//		Seed("Country", func() {
+//			Row(map[string]interface{}{"Code": "FR", "Name": "France"})
+//			Row(map[string]interface{}{"Code": "NL", "Name": "Netherlands"})
+//		})
+//	})
+//
+// Each row must set the primary key of the model or a unique field: the
+// rows are matched on it with the existing ones.
+func Seed(model string, dsl func()) {
+	var store *gorma.RelationalStoreDefinition
+	if m, ok := relationalModelDefinition(false); ok {
+		store = m.Parent
+		if model == "" {
+			model = m.ModelName
+		}
+	} else if s, ok := relationalStoreDefinition(true); ok {
+		store = s
+	}
+	if store == nil {
+		return
+	}
+	if model == "" {
+		dslengine.ReportError("Seed requires a model name")
+		return
+	}
+	store.Seeds = append(store.Seeds, &gorma.SeedDefinition{
+		DefinitionDSL: dsl,
+		Parent:        store,
+		ModelName:     model,
+	})
+}
+
+// Row adds a row to a Seed.  The keys are the names of the fields of the
+// model, or of their columns, and the values must match the field types:
+// bools, numbers and strings, RFC 3339 strings for timestamps.  nil sets
+// a nullable field to NULL.
+func Row(values map[string]interface{}) {
+	if s, ok := seedDefinition(true); ok {
+		row := make(map[string]interface{}, len(values))
+		for name, value := range values {
+			field := SanitizeFieldName(name)
+			if _, ok := row[field]; ok {
+				dslengine.ReportError("field %s set twice in a row of the %s seed", field, s.ModelName)
+				continue
+			}
+			row[field] = value
+		}
+		s.Rows = append(s.Rows, row)
+	}
+}
@@ -0,0 +1,125 @@
+package dsl_test
+
+import (
+	"github.com/Gys/gorma"
+	gdsl "github.com/Gys/gorma/dsl"
+
+	. "github.com/Gys/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Seed", func() {
+	var storename string
+	var dsl, modeldsl func()
+
+	BeforeEach(func() {
+		Reset()
+		storename = "postgres"
+		dsl = func() {}
+		modeldsl = func() {}
+	})
+
+	JustBeforeEach(func() {
+		gdsl.StorageGroup("production", func() {
+			gdsl.Store(storename, gorma.Postgres, func() {
+				gdsl.Model("Country", func() {
+					gdsl.Field("Code", gorma.String, func() {
+						gdsl.Unique()
+					})
+					gdsl.Field("Name", gorma.String)
+					modeldsl()
+				})
+				gdsl.Model("City", func() {
+					gdsl.BelongsTo("Country")
+					gdsl.Field("Name", gorma.String)
+				})
+				dsl()
+			})
+		})
+		Run()
+	})
+
+	Context("with valid rows", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				gdsl.Seed("City", func() {
+					gdsl.Row(map[string]interface{}{"id": 1, "country_id": 1, "Name": "Paris"})
+				})
+			}
+			modeldsl = func() {
+				gdsl.Seed("", func() {
+					gdsl.Row(map[string]interface{}{"Code": "FR", "Name": "France"})
+				})
+			}
+		})
+
+		It("records the rows by field name", func() {
+			Ω(Errors).ShouldNot(HaveOccurred())
+			seeds := gorma.GormaDesign.RelationalStores[storename].Seeds
+			Ω(seeds).Should(HaveLen(2))
+			Ω(seeds[0].ModelName).Should(Equal("City"))
+			Ω(seeds[1].ModelName).Should(Equal("Country"))
+			Ω(seeds[0].Rows).Should(Equal([]map[string]interface{}{{"ID": 1, "CountryID": 1, "Name": "Paris"}}))
+		})
+
+		It("upserts the parents first", func() {
+			rows := gorma.GormaDesign.RelationalStores[storename].SeedRows()
+			Ω(rows).Should(MatchRegexp(`(?s)^\{"Country", .*\n\{"City", `))
+		})
+	})
+
+	Context("with an unknown field", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				gdsl.Seed("Country", func() {
+					gdsl.Row(map[string]interface{}{"Code": "FR", "Population": 68})
+				})
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with a value of the wrong type", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				gdsl.Seed("Country", func() {
+					gdsl.Row(map[string]interface{}{"Code": 33})
+				})
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with a row without key", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				gdsl.Seed("City", func() {
+					gdsl.Row(map[string]interface{}{"Name": "Paris"})
+				})
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with an unknown model", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				gdsl.Seed("Role", func() {})
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(Errors).Should(HaveOccurred())
+		})
+	})
+})
@@ -28,6 +28,7 @@ func StorageGroup(name string, dsli func()) *gorma.StorageGroupDefinition {
 
 // Description sets the definition description.
 // Description can be called inside StorageGroup, RelationalStore, RelationalModel, RelationalField
+// and the adapters of a model.
 func Description(d string) {
 	if a, ok := storageGroupDefinition(false); ok {
 		a.Description = d
@@ -37,5 +38,11 @@ func Description(d string) {
 		r.Description = d
 	} else if f, ok := relationalFieldDefinition(false); ok {
 		f.Description = d
+	} else if a, ok := dslengine.CurrentDefinition().(*gorma.MediaTypeAdapterDefinition); ok {
+		a.Description = d
+	} else if a, ok := dslengine.CurrentDefinition().(*gorma.UserTypeAdapterDefinition); ok {
+		a.Description = d
+	} else if a, ok := dslengine.CurrentDefinition().(*gorma.PayloadAdapterDefinition); ok {
+		a.Description = d
 	}
 }
@@ -0,0 +1,243 @@
+package gorma
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Gys/goa/design"
+)
+
+// factoryLiteral returns the Go literal of a value of the field's type.
+func factoryLiteral(f *RelationalFieldDefinition, v interface{}) string {
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprintf("%s(%v)", keyGoDatatype(f), v)
+}
+
+// factoryNumber returns the expression of the n-th value of a numeric
+// field, within the minimum and maximum of its validation.
+func factoryNumber(f *RelationalFieldDefinition) string {
+	t := keyGoDatatype(f)
+	var lo, hi *float64
+	if f.Validation != nil {
+		lo, hi = f.Validation.Minimum, f.Validation.Maximum
+	}
+	min := 0.0
+	switch {
+	case lo != nil:
+		min = *lo
+	case hi != nil && *hi < 0:
+		min = *hi
+	}
+	switch t {
+	case "float32", "float64":
+		if hi != nil {
+			return fmt.Sprintf("%s(%v + float64(n%%%d))", t, min, int(*hi-min)+1)
+		}
+		return fmt.Sprintf("%s(%v + float64(n))", t, min)
+	}
+	if hi != nil {
+		return fmt.Sprintf("%s(%d + n%%%d)", t, int(min), int(*hi-min)+1)
+	}
+	return fmt.Sprintf("%s(%d + n)", t, int(min))
+}
+
+// factoryFormat returns the format of the n-th value of a string field:
+// the column name followed by n, fitting the size of the column.
+func factoryFormat(f *RelationalFieldDefinition) string {
+	max := factoryMaxLength(f)
+	prefix := f.ColumnName() + "-"
+	if max > 0 && len(prefix) > max-4 {
+		// keep room for four digits
+		prefix = prefix[:0]
+		if max > 4 {
+			prefix = f.ColumnName()[:max-4]
+		}
+	}
+	if v := f.Validation; v != nil && v.MinLength != nil && *v.MinLength > len(prefix)+1 {
+		return fmt.Sprintf("%s%%0%dd", prefix, *v.MinLength-len(prefix))
+	}
+	return prefix + "%d"
+}
+
+// factoryMaxLength returns the maximum length of the values of a string
+// field, 0 if unlimited.
+func factoryMaxLength(f *RelationalFieldDefinition) int {
+	max := 0
+	if f.Datatype == String || f.Size > 0 {
+		max = fieldSize(f)
+	}
+	if v := f.Validation; v != nil && v.MaxLength != nil && (max == 0 || *v.MaxLength < max) {
+		max = *v.MaxLength
+	}
+	return max
+}
+
+// factoryFits returns true if the values of the format satisfy the length
+// and pattern validations of a string field, checked on a short and a long
+// sequence number.
+func factoryFits(f *RelationalFieldDefinition, format string) bool {
+	var pattern *regexp.Regexp
+	if v := f.Validation; v != nil && v.Pattern != "" {
+		re, err := regexp.Compile(v.Pattern)
+		if err != nil {
+			return false
+		}
+		pattern = re
+	}
+	for _, n := range []int{1, 9999} {
+		s := fmt.Sprintf(format, n)
+		if max := factoryMaxLength(f); max > 0 && len(s) > max {
+			return false
+		}
+		if v := f.Validation; v != nil && v.MinLength != nil && len(s) < *v.MinLength {
+			return false
+		}
+		if pattern != nil && !pattern.MatchString(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// factoryValue returns the expression of the value the factory gives the
+// field in the n-th record: the default of the field, a value satisfying
+// its validations or a value of its type.  Values depend on n, when
+// sequence is true, so that unique columns get distinct values as far as
+// the validations allow.
+func factoryValue(f *RelationalFieldDefinition) (value string, sequence bool) {
+	if f.Default != nil {
+		return goDefault(f), false
+	}
+	if v := f.Validation; v != nil && len(v.Values) > 0 {
+		vals := make([]string, len(v.Values))
+		for i, val := range v.Values {
+			vals[i] = factoryLiteral(f, val)
+		}
+		return fmt.Sprintf("[]%s{%s}[n%%%d]", keyGoDatatype(f), strings.Join(vals, ", "), len(vals)), true
+	}
+	switch keyDatatype(f) {
+	case Boolean:
+		return "false", false
+	case Integer, BigInteger, Decimal, BigDecimal:
+		return factoryNumber(f), true
+	case UUID:
+		return "uuid.Must(uuid.NewV4())", false
+	case Timestamp, NullableTimestamp:
+		return "time.Now()", false
+	case JSON:
+		if f.Dialect() == Postgres {
+			return `postgres.Jsonb{RawMessage: json.RawMessage("{}")}`, false
+		}
+		return `json.RawMessage("{}")`, false
+	case String, Text:
+		formats := []string{factoryFormat(f), "user%d@example.com"}
+		v := f.Validation
+		if v != nil && v.Format == "email" {
+			formats = formats[1:]
+		} else if v != nil && v.Format != "" {
+			formats = nil
+		}
+		for _, format := range formats {
+			if factoryFits(f, format) {
+				return fmt.Sprintf("fmt.Sprintf(%q, n)", format), true
+			}
+		}
+		// last resort: the example of the validations, the same value
+		// for all the records
+		if att := f.validationAttribute(); att != nil {
+			if ex := att.GenerateExample(design.NewRandomGenerator(f.Parent.ModelName+f.FieldName), nil); ex != nil {
+				return factoryLiteral(f, ex), false
+			}
+		}
+		return fmt.Sprintf("fmt.Sprintf(%q, n)", factoryFormat(f)), true
+	}
+	return "", false
+}
+
+// factoryParents returns the models the model belongs to whose records the
+// factory creates along with its own: the parents of the non-nullable
+// foreign keys, other than the model itself.
+func (f *RelationalModelDefinition) factoryParents() []*RelationalModelDefinition {
+	var parents []*RelationalModelDefinition
+	for _, name := range sortedKeys(f.BelongsTo) {
+		parent := f.storeModel(f.BelongsTo[name].ModelName)
+		if parent == nil || parent == f || parent.ModelName == f.ModelName {
+			continue
+		}
+		nullable := false
+		for _, key := range f.foreignKeyFields(parent.ModelName) {
+			nullable = nullable || key.Nullable
+		}
+		if !nullable {
+			parents = append(parents, parent)
+		}
+	}
+	return parents
+}
+
+// FactoryParents returns the names of the models the factory of the model
+// creates the parents of the records from, see factoryParents.
+func (f *RelationalModelDefinition) FactoryParents() []string {
+	var names []string
+	for _, parent := range f.factoryParents() {
+		names = append(names, parent.ModelName)
+	}
+	return names
+}
+
+// FactoryFields returns the code of the factory setting the fields of the
+// record m, the n-th record of the factory: the columns other than the
+// foreign keys, the timestamps, the nullable columns and the generated
+// primary keys.
+func (f *RelationalModelDefinition) FactoryFields() string {
+	skip := make(map[string]bool)
+	for _, name := range sortedKeys(f.BelongsTo) {
+		for _, key := range f.foreignKeyFields(f.BelongsTo[name].ModelName) {
+			skip[key.FieldName] = true
+		}
+	}
+	for _, name := range []string{"CreatedAt", "UpdatedAt", "DeletedAt"} {
+		skip[name] = true
+	}
+	var code []string
+	sequence := false
+	for _, field := range f.columnFields() {
+		if skip[field.FieldName] || field.Nullable || keyGenerator(field) != "" || isRelationshipKey(field) {
+			continue
+		}
+		value, seq := factoryValue(field)
+		if value != "" {
+			code = append(code, fmt.Sprintf("m.%s = %s", field.FieldName, value))
+			sequence = sequence || seq
+		}
+	}
+	if sequence {
+		code = append([]string{"n := f.next()"}, code...)
+	}
+	return strings.Join(code, "\n")
+}
+
+// FactoryParentCode returns the code of the factory creating the parent of
+// the record m with the factory of the parent model, when the foreign key
+// of m isn't set.
+func (f *RelationalModelDefinition) FactoryParentCode(parent string) string {
+	keys := f.foreignKeyFields(parent)
+	pks := f.storeModel(parent).PrimaryKeyFields()
+	var unset, assign []string
+	for i, key := range keys {
+		unset = append(unset, fmt.Sprintf("m.%s == %s", key.FieldName, zeroValue(keyDatatype(key))))
+		if i < len(pks) {
+			assign = append(assign, fmt.Sprintf("\tm.%s = parent.%s", key.FieldName, pks[i].FieldName))
+		}
+	}
+	return fmt.Sprintf(`if %s {
+	parent, err := f.%s.Create(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+%s
+}`, strings.Join(unset, " && "), parent, strings.Join(assign, "\n"))
+}
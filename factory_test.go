@@ -0,0 +1,79 @@
+package gorma_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Gys/goa/dslengine"
+	"github.com/Gys/gorma"
+)
+
+func TestFactoryFields(t *testing.T) {
+	sd := makeStore(gorma.Postgres)
+	user := makeModel(sd, "User")
+	email := makeField(user, "Email", gorma.String)
+	pattern := `^\S+@\S+$`
+	email.Validation = &dslengine.ValidationDefinition{Pattern: pattern}
+	makeField(user, "Nickname", gorma.String).Nullable = true
+	makeField(user, "Age", gorma.Integer)
+	makeField(user, "CreatedAt", gorma.Timestamp)
+	order := makeModel(sd, "Order")
+	makeField(order, "UserID", gorma.BelongsTo)
+	order.BelongsTo["User"] = user
+	makeField(order, "Note", gorma.String).Default = "none"
+
+	code := user.FactoryFields()
+	for _, exp := range []string{
+		"n := f.next()\n",
+		`m.Email = fmt.Sprintf("user%d@example.com", n)`,
+		"m.Age = int(0 + n)",
+	} {
+		if !strings.Contains(code, exp) {
+			t.Errorf("expected %q in %q", exp, code)
+		}
+	}
+	for _, field := range []string{"m.ID", "m.Nickname", "m.CreatedAt"} {
+		if strings.Contains(code, field) {
+			t.Errorf("unexpected %s in %q", field, code)
+		}
+	}
+
+	exp := `m.Note = "none"`
+	if code := order.FactoryFields(); code != exp {
+		t.Errorf("expected %q, got %q", exp, code)
+	}
+	if parents := order.FactoryParents(); len(parents) != 1 || parents[0] != "User" {
+		t.Errorf("unexpected parents %v", parents)
+	}
+	exp = "if m.UserID == 0 {\n\tparent, err := f.User.Create(ctx, repo)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n\tm.UserID = parent.ID\n}"
+	if code := order.FactoryParentCode("User"); code != exp {
+		t.Errorf("expected %q, got %q", exp, code)
+	}
+}
+
+func TestFactoryValidations(t *testing.T) {
+	min, max := 1.0, 5.0
+	minLength, maxLength := 8, 10
+	var tests = []struct {
+		datatype   gorma.FieldType
+		size       int
+		validation *dslengine.ValidationDefinition
+		expected   string
+	}{
+		{gorma.Integer, 0, &dslengine.ValidationDefinition{Minimum: &min, Maximum: &max}, "m.Value = int(1 + n%5)"},
+		{gorma.String, 0, &dslengine.ValidationDefinition{Values: []interface{}{"a", "b"}}, `m.Value = []string{"a", "b"}[n%2]`},
+		{gorma.String, 0, &dslengine.ValidationDefinition{MinLength: &minLength}, `m.Value = fmt.Sprintf("value-%02d", n)`},
+		{gorma.String, 0, &dslengine.ValidationDefinition{MaxLength: &maxLength}, `m.Value = fmt.Sprintf("value-%d", n)`},
+		{gorma.String, 6, nil, `m.Value = fmt.Sprintf("va%d", n)`},
+		{gorma.Boolean, 0, nil, "m.Value = false"},
+	}
+	for _, tt := range tests {
+		m := makeModel(makeStore(gorma.Postgres), "Item")
+		f := makeField(m, "Value", tt.datatype)
+		f.Size = tt.size
+		f.Validation = tt.validation
+		if code := m.FactoryFields(); !strings.Contains(code, tt.expected) {
+			t.Errorf("expected %q in %q", tt.expected, code)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package gorma
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Gys/goa/goagen/codegen"
+)
+
+// fakeValue returns the expressions of a pointer to the value of a field
+// of the v model variable and of the condition under which the value is
+// set: nullable fields are pointers already, nil when NULL.
+func fakeValue(v string, field *RelationalFieldDefinition) (string, string) {
+	value := v + "." + field.FieldName
+	if field.Nullable {
+		return value, value + " != nil"
+	}
+	return "&" + value, ""
+}
+
+// fakeSet returns the condition under which gorm writes a field of the
+// model variable v with Updates: the value is not blank.
+func fakeSet(v string, field *RelationalFieldDefinition) string {
+	value := v + "." + field.FieldName
+	if field.Nullable {
+		return value + " != nil"
+	}
+	switch keyDatatype(field) {
+	case Boolean:
+		return value
+	case String, Text:
+		return value + ` != ""`
+	case UUID:
+		return value + " != uuid.Nil"
+	case Timestamp, NullableTimestamp:
+		return "!" + value + ".IsZero()"
+	case JSON:
+		if field.Dialect() == Postgres {
+			return "len(" + value + ".RawMessage) > 0"
+		}
+		return "len(" + value + ") > 0"
+	}
+	return value + " != 0"
+}
+
+// SoftDelete returns true if gorm soft deletes the records of the model: it
+// has a DeletedAt field.
+func (f *RelationalModelDefinition) SoftDelete() bool {
+	_, ok := f.RelationalFields["DeletedAt"]
+	return ok
+}
+
+// FakeTimestamps returns the code of the fake storage setting the
+// timestamps gorm sets when it creates or updates the record held by the
+// v variable: CreatedAt when not set yet and UpdatedAt.
+func (f *RelationalModelDefinition) FakeTimestamps(v string, create bool) string {
+	names := []string{"UpdatedAt"}
+	if create {
+		names = []string{"CreatedAt", "UpdatedAt"}
+	}
+	var code []string
+	for _, name := range names {
+		field, ok := f.RelationalFields[name]
+		if !ok {
+			continue
+		}
+		value := v + "." + name
+		now := "now"
+		if field.Nullable {
+			now = "&now"
+		}
+		if name == "CreatedAt" {
+			guard := value + ".IsZero()"
+			if field.Nullable {
+				guard = value + " == nil"
+			}
+			code = append(code, fmt.Sprintf("if %s {\n\t%s = %s\n}", guard, value, now))
+			continue
+		}
+		code = append(code, fmt.Sprintf("%s = %s", value, now))
+	}
+	if len(code) == 0 {
+		return ""
+	}
+	return "now := time.Now()\n" + strings.Join(code, "\n")
+}
+
+// FakeUpdates returns the code of the fake storage copying the fields of
+// model to obj the way gorm's Updates does: blank fields are left alone.
+func (f *RelationalModelDefinition) FakeUpdates() string {
+	var code []string
+	for _, field := range f.columnFields() {
+		if field.PrimaryKey {
+			continue
+		}
+		code = append(code, fmt.Sprintf("if %s {\n\tobj.%s = model.%s\n}", fakeSet("model", field), field.FieldName, field.FieldName))
+	}
+	return strings.Join(code, "\n")
+}
+
+// FakeFilterCode returns the body of the method of the model's filter
+// struct reporting whether the m record matches the filter, the in-memory
+// counterpart of FilterCode.
+func (f *RelationalModelDefinition) FakeFilterCode() string {
+	var code []string
+	fields, ops := f.filterOps()
+	for i, field := range fields {
+		value, set := fakeValue("m", field)
+		if set != "" {
+			set += " && "
+		}
+		for _, op := range ops[i] {
+			name := "filter." + field.FieldName + filterSuffixes[op]
+			switch op {
+			case FilterIn:
+				code = append(code, fmt.Sprintf(`if len(%s) > 0 {
+	found := false
+	for i := range %s {
+		found = found || (%sfakeCompare(%s, &%s[i]) == 0)
+	}
+	if !found {
+		return false
+	}
+}`, name, name, set, value, name))
+			case FilterIsNull:
+				null := "false"
+				if field.Nullable {
+					null = value + " == nil"
+				}
+				code = append(code, fmt.Sprintf("if %s != nil && (%s) != *%s {\n\treturn false\n}", name, null, name))
+			case FilterLike:
+				code = append(code, fmt.Sprintf("if %s != nil && !(%sfakeLike(*%s, *%s)) {\n\treturn false\n}", name, set, value, name))
+			default:
+				cmp := map[FilterOperator]string{FilterEq: "==", FilterNe: "!=", FilterLt: "<", FilterGt: ">"}[op]
+				code = append(code, fmt.Sprintf("if %s != nil && !(%sfakeCompare(%s, %s) %s 0) {\n\treturn false\n}", name, set, value, name, cmp))
+			}
+		}
+	}
+	return strings.Join(code, "\n")
+}
+
+// BelongsToMatch returns the condition under which the record held by the
+// v variable is a child of the parent identified by the parameters built by
+// BelongsToAttributes, see BelongsToGuard.
+func (f *RelationalModelDefinition) BelongsToMatch(parent, v string) string {
+	var match []string
+	for _, key := range f.foreignKeyFields(parent) {
+		name := codegen.Goify(key.FieldName, false)
+		value := v + "." + key.FieldName
+		if key.Nullable {
+			match = append(match, fmt.Sprintf("%s != nil && *%s == %s", value, value, name))
+			continue
+		}
+		match = append(match, value+" == "+name)
+	}
+	return strings.Join(match, " && ")
+}
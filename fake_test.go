@@ -0,0 +1,80 @@
+package gorma_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Gys/gorma"
+)
+
+func TestFakeKeyGeneration(t *testing.T) {
+	m := makeModel(makeStore(gorma.Postgres), "User")
+
+	exp := "if model.ID == 0 {\n\tf.lastID++\n\tmodel.ID = f.lastID\n}\n"
+	if code := m.FakeKeyGeneration(); code != exp {
+		t.Errorf("expected %q, got %q", exp, code)
+	}
+	if counters := m.FakeCounters(); counters != "lastID int" {
+		t.Errorf("unexpected counters %q", counters)
+	}
+	if code := m.KeyGeneration(); code != "" {
+		t.Errorf("expected no key generation, got %q", code)
+	}
+}
+
+func TestFakeUpdates(t *testing.T) {
+	m := makeModel(makeStore(gorma.Postgres), "User")
+	makeField(m, "Name", gorma.String)
+	makeField(m, "Admin", gorma.Boolean)
+	makeField(m, "Bio", gorma.Text).Nullable = true
+
+	code := m.FakeUpdates()
+	for _, exp := range []string{
+		"if model.Name != \"\" {\n\tobj.Name = model.Name\n}",
+		"if model.Admin {\n\tobj.Admin = model.Admin\n}",
+		"if model.Bio != nil {\n\tobj.Bio = model.Bio\n}",
+	} {
+		if !strings.Contains(code, exp) {
+			t.Errorf("expected %q in %q", exp, code)
+		}
+	}
+	if strings.Contains(code, "obj.ID") {
+		t.Errorf("primary key updated in %q", code)
+	}
+}
+
+func TestFakeFilterCode(t *testing.T) {
+	m := makeModel(makeStore(gorma.Postgres), "User")
+	name := makeField(m, "Name", gorma.String)
+	name.Nullable = true
+	name.FilterOps = []gorma.FilterOperator{gorma.FilterEq, gorma.FilterLike, gorma.FilterIsNull}
+
+	code := m.FakeFilterCode()
+	for _, exp := range []string{
+		"if filter.Name != nil && !(m.Name != nil && fakeCompare(m.Name, filter.Name) == 0) {",
+		"if filter.NameLike != nil && !(m.Name != nil && fakeLike(*m.Name, *filter.NameLike)) {",
+		"if filter.NameIsNull != nil && (m.Name == nil) != *filter.NameIsNull {",
+	} {
+		if !strings.Contains(code, exp) {
+			t.Errorf("expected %q in %q", exp, code)
+		}
+	}
+}
+
+func TestFakeTimestamps(t *testing.T) {
+	m := makeModel(makeStore(gorma.Postgres), "User")
+	makeField(m, "CreatedAt", gorma.Timestamp)
+	makeField(m, "UpdatedAt", gorma.Timestamp)
+
+	exp := "now := time.Now()\nif model.CreatedAt.IsZero() {\n\tmodel.CreatedAt = now\n}\nmodel.UpdatedAt = now"
+	if code := m.FakeTimestamps("model", true); code != exp {
+		t.Errorf("expected %q, got %q", exp, code)
+	}
+	exp = "now := time.Now()\nobj.UpdatedAt = now"
+	if code := m.FakeTimestamps("obj", false); code != exp {
+		t.Errorf("expected %q, got %q", exp, code)
+	}
+	if m.SoftDelete() {
+		t.Error("expected no soft delete")
+	}
+}
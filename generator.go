@@ -3,6 +3,7 @@ package gorma
 import (
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -19,11 +20,15 @@ type Generator struct {
 	target     string   // Target package name - "models" by default
 	appPkg     string   // Generated goa app package name - "app" by default
 	appPkgPath string   // Generated goa app package import path
+	migDir     string   // Absolute path to the SQL migrations directory
+	legacy     bool     // Generate the List<MediaType> methods without options and error
+	mocks      bool     // Generate the call recording mocks of the storages
 }
 
 // Generate is the generator entry point called by the meta generator.
 func Generate() (files []string, err error) {
-	var outDir, target, appPkg, ver string
+	var outDir, target, appPkg, migDir, ver string
+	var legacy, mocks bool
 
 	set := flag.NewFlagSet("gorma", flag.PanicOnError)
 	set.String("design", "", "")
@@ -31,6 +36,9 @@ func Generate() (files []string, err error) {
 	set.StringVar(&ver, "version", "", "")
 	set.StringVar(&target, "pkg", "models", "")
 	set.StringVar(&appPkg, "app", "app", "")
+	set.StringVar(&migDir, "migrations", "migrations", "")
+	set.BoolVar(&legacy, "legacy-lists", false, "")
+	set.BoolVar(&mocks, "mocks", false, "")
 	set.Parse(os.Args[2:])
 
 	// First check compatibility
@@ -43,9 +51,13 @@ func Generate() (files []string, err error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid app package: %s", err)
 	}
+	if migDir != "" {
+		// an empty directory turns the migrations off
+		migDir = filepath.Join(outDir, migDir)
+	}
 	outDir = filepath.Join(outDir, target)
 
-	g := &Generator{outDir: outDir, target: target, appPkg: appPkg, appPkgPath: appPkgPath}
+	g := &Generator{outDir: outDir, target: target, appPkg: appPkg, appPkgPath: appPkgPath, migDir: migDir, legacy: legacy, mocks: mocks}
 
 	return g.Generate(design.Design)
 }
@@ -71,6 +83,36 @@ func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error)
 	if err := g.generateUserHelpers(g.outDir, api); err != nil {
 		return g.genfiles, err
 	}
+	if err := g.generateHelpers(g.outDir, api); err != nil {
+		return g.genfiles, err
+	}
+	if err := g.generateRepositories(g.outDir, api); err != nil {
+		return g.genfiles, err
+	}
+	if err := g.generateFakes(g.outDir, api); err != nil {
+		return g.genfiles, err
+	}
+	if err := g.generateMocks(g.outDir, api); err != nil {
+		return g.genfiles, err
+	}
+	if err := g.generateFactories(g.outDir, api); err != nil {
+		return g.genfiles, err
+	}
+	if err := g.generateRoundTripTests(g.outDir, api); err != nil {
+		return g.genfiles, err
+	}
+	if err := g.generateAdapters(g.outDir, api); err != nil {
+		return g.genfiles, err
+	}
+	if err := g.generateSeeds(g.outDir, api); err != nil {
+		return g.genfiles, err
+	}
+	if err := g.generateTestHelpers(g.outDir, api); err != nil {
+		return g.genfiles, err
+	}
+	if err := g.generateMigrations(g.migDir); err != nil {
+		return g.genfiles, err
+	}
 
 	return g.genfiles, nil
 }
@@ -106,16 +148,21 @@ func (g *Generator) generateUserTypes(outdir string, api *design.APIDefinition)
 			imports := []*codegen.ImportSpec{
 				codegen.SimpleImport(g.appPkgPath),
 				codegen.SimpleImport("context"),
+				codegen.SimpleImport("encoding/json"),
+				codegen.SimpleImport("errors"),
 				codegen.SimpleImport("time"),
+				codegen.SimpleImport("unicode/utf8"),
 				codegen.SimpleImport("github.com/Gys/goa"),
 				codegen.SimpleImport("github.com/jinzhu/gorm"),
+				codegen.SimpleImport("github.com/jinzhu/gorm/dialects/postgres"),
 				codegen.SimpleImport("github.com/gofrs/uuid"),
+				codegen.NewImport("ulid", "github.com/oklog/ulid/v2"),
 			}
 
 			if model.Cached {
 				imp := codegen.NewImport("cache", "github.com/patrickmn/go-cache")
 				imports = append(imports, imp)
-				imp = codegen.SimpleImport("strconv")
+				imp = codegen.SimpleImport("fmt")
 				imports = append(imports, imp)
 			}
 			utWr.WriteHeader(title, g.target, imports)
@@ -124,6 +171,7 @@ func (g *Generator) generateUserTypes(outdir string, api *design.APIDefinition)
 				UserType:      model,
 				DefaultPkg:    g.target,
 				AppPkg:        g.appPkgPath,
+				LegacyLists:   g.legacy,
 			}
 			err = utWr.Execute(data)
 			g.genfiles = append(g.genfiles, utFile)
@@ -169,16 +217,18 @@ func (g *Generator) generateUserHelpers(outdir string, api *design.APIDefinition
 			imports := []*codegen.ImportSpec{
 				codegen.SimpleImport(g.appPkgPath),
 				codegen.SimpleImport("context"),
+				codegen.SimpleImport("encoding/json"),
 				codegen.SimpleImport("time"),
 				codegen.SimpleImport("github.com/Gys/goa"),
 				codegen.SimpleImport("github.com/jinzhu/gorm"),
+				codegen.SimpleImport("github.com/jinzhu/gorm/dialects/postgres"),
 				codegen.NewImport("uuid", "github.com/satori/go.uuid"),
 			}
 
 			if model.Cached {
 				imp := codegen.NewImport("cache", "github.com/patrickmn/go-cache")
 				imports = append(imports, imp)
-				imp = codegen.SimpleImport("strconv")
+				imp = codegen.SimpleImport("fmt")
 				imports = append(imports, imp)
 			}
 			utWr.WriteHeader(title, g.target, imports)
@@ -187,6 +237,7 @@ func (g *Generator) generateUserHelpers(outdir string, api *design.APIDefinition
 				UserType:      model,
 				DefaultPkg:    g.target,
 				AppPkg:        g.appPkgPath,
+				LegacyLists:   g.legacy,
 			}
 			err = utWr.Execute(data)
 			g.genfiles = append(g.genfiles, utFile)
@@ -204,3 +255,407 @@ func (g *Generator) generateUserHelpers(outdir string, api *design.APIDefinition
 	})
 	return err
 }
+
+// generateHelpers writes the helpers shared by the models: the list
+// helpers, the storage errors and the transaction helpers.
+func (g *Generator) generateHelpers(outdir string, api *design.APIDefinition) error {
+	err := g.writeHelpers(filepath.Join(outdir, "list.go"), fmt.Sprintf("%s: List Helpers", api.Context()), "", listT, nil, []*codegen.ImportSpec{
+		codegen.SimpleImport("encoding/base64"),
+		codegen.SimpleImport("encoding/json"),
+		codegen.SimpleImport("fmt"),
+		codegen.SimpleImport("strings"),
+		codegen.SimpleImport("github.com/jinzhu/gorm"),
+	})
+	if err != nil {
+		return err
+	}
+	err = g.writeHelpers(filepath.Join(outdir, "errors.go"), fmt.Sprintf("%s: Storage Errors", api.Context()), "", errorsT, nil, []*codegen.ImportSpec{
+		codegen.SimpleImport("errors"),
+		codegen.SimpleImport("fmt"),
+		codegen.SimpleImport("strings"),
+		codegen.SimpleImport("github.com/jinzhu/gorm"),
+	})
+	if err != nil {
+		return err
+	}
+	return g.writeHelpers(filepath.Join(outdir, "tx.go"), fmt.Sprintf("%s: Transactions", api.Context()), "", txT, nil, []*codegen.ImportSpec{
+		codegen.SimpleImport("context"),
+		codegen.SimpleImport("fmt"),
+		codegen.SimpleImport("time"),
+		codegen.SimpleImport("github.com/jinzhu/gorm"),
+	})
+}
+
+// generateRepositories writes the repository of each store.
+func (g *Generator) generateRepositories(outdir string, api *design.APIDefinition) error {
+	return GormaDesign.IterateStores(func(store *RelationalStoreDefinition) error {
+		filename := filepath.Join(outdir, codegen.SnakeCase(store.RepositoryName())+".go")
+		return g.writeHelpers(filename, fmt.Sprintf("%s: %s Repository", api.Context(), store.Name), "", repositoryT, store, []*codegen.ImportSpec{
+			codegen.SimpleImport("context"),
+			codegen.SimpleImport("github.com/jinzhu/gorm"),
+		})
+	})
+}
+
+// fakeBuildTag is the build tag of the fake storages and of the mocks,
+// tests using them run with go test -tags gormafake.
+const fakeBuildTag = "gormafake"
+
+// generateFakes writes the in-memory fake storage of each model and the
+// helpers they share, behind the fake build tag.
+func (g *Generator) generateFakes(outdir string, api *design.APIDefinition) error {
+	err := g.writeHelpers(filepath.Join(outdir, "fake.go"), fmt.Sprintf("%s: Fake Storage Helpers", api.Context()), fakeBuildTag, fakeHelpersT, nil, []*codegen.ImportSpec{
+		codegen.SimpleImport("bytes"),
+		codegen.SimpleImport("fmt"),
+		codegen.SimpleImport("regexp"),
+		codegen.SimpleImport("strings"),
+		codegen.SimpleImport("time"),
+		codegen.SimpleImport("github.com/gofrs/uuid"),
+	})
+	if err != nil {
+		return err
+	}
+	return GormaDesign.IterateStores(func(store *RelationalStoreDefinition) error {
+		return store.IterateModels(func(model *RelationalModelDefinition) error {
+			filename := filepath.Join(outdir, fmt.Sprintf("%s_fake.go", strings.ToLower(codegen.Goify(model.ModelName, false))))
+			if err := os.RemoveAll(filename); err != nil {
+				fmt.Println(err)
+			}
+			fakeWr, err := NewUserFakeWriter(filename)
+			if err != nil {
+				panic(err) // bug
+			}
+			if err := writeBuildTag(fakeWr.SourceFile, fakeBuildTag); err != nil {
+				return err
+			}
+			title := fmt.Sprintf("%s: Fake Storages", api.Context())
+			imports := []*codegen.ImportSpec{
+				codegen.SimpleImport(g.appPkgPath),
+				codegen.SimpleImport("context"),
+				codegen.SimpleImport("errors"),
+				codegen.SimpleImport("fmt"),
+				codegen.SimpleImport("sort"),
+				codegen.SimpleImport("sync"),
+				codegen.SimpleImport("time"),
+				codegen.SimpleImport("github.com/jinzhu/gorm"),
+				codegen.SimpleImport("github.com/gofrs/uuid"),
+				codegen.NewImport("ulid", "github.com/oklog/ulid/v2"),
+			}
+			if err := fakeWr.WriteHeader(title, g.target, imports); err != nil {
+				return err
+			}
+			data := &UserTypeTemplateData{
+				APIDefinition: api,
+				UserType:      model,
+				DefaultPkg:    g.target,
+				AppPkg:        g.appPkgPath,
+				LegacyLists:   g.legacy,
+			}
+			g.genfiles = append(g.genfiles, filename)
+			if err := fakeWr.Execute(data); err != nil {
+				return err
+			}
+			return fakeWr.FormatCode()
+		})
+	})
+}
+
+// generateMocks writes the call recording mock of the storage of each
+// model, behind the fake build tag, when the mocks are enabled.  Mocks of
+// a previous run are removed otherwise, they would not match the storage
+// interfaces anymore.
+func (g *Generator) generateMocks(outdir string, api *design.APIDefinition) error {
+	return GormaDesign.IterateStores(func(store *RelationalStoreDefinition) error {
+		return store.IterateModels(func(model *RelationalModelDefinition) error {
+			filename := filepath.Join(outdir, fmt.Sprintf("%s_mock.go", strings.ToLower(codegen.Goify(model.ModelName, false))))
+			if !g.mocks {
+				return os.RemoveAll(filename)
+			}
+			data := &UserTypeTemplateData{
+				APIDefinition: api,
+				UserType:      model,
+				DefaultPkg:    g.target,
+				AppPkg:        g.appPkgPath,
+				LegacyLists:   g.legacy,
+			}
+			return g.writeHelpers(filename, fmt.Sprintf("%s: Storage Mocks", api.Context()), fakeBuildTag, mockT, data, []*codegen.ImportSpec{
+				codegen.SimpleImport(g.appPkgPath),
+				codegen.SimpleImport("context"),
+				codegen.SimpleImport("sync"),
+				codegen.SimpleImport("github.com/gofrs/uuid"),
+			})
+		})
+	})
+}
+
+// generateFactories writes the factory of the records of each model.
+func (g *Generator) generateFactories(outdir string, api *design.APIDefinition) error {
+	return GormaDesign.IterateStores(func(store *RelationalStoreDefinition) error {
+		return store.IterateModels(func(model *RelationalModelDefinition) error {
+			filename := filepath.Join(outdir, fmt.Sprintf("%s_factory.go", strings.ToLower(codegen.Goify(model.ModelName, false))))
+			data := &UserTypeTemplateData{
+				APIDefinition: api,
+				UserType:      model,
+				DefaultPkg:    g.target,
+				AppPkg:        g.appPkgPath,
+				LegacyLists:   g.legacy,
+			}
+			return g.writeHelpers(filename, fmt.Sprintf("%s: Factories", api.Context()), "", factoryT, data, []*codegen.ImportSpec{
+				codegen.SimpleImport("context"),
+				codegen.SimpleImport("encoding/json"),
+				codegen.SimpleImport("fmt"),
+				codegen.SimpleImport("sync"),
+				codegen.SimpleImport("time"),
+				codegen.SimpleImport("github.com/gofrs/uuid"),
+				codegen.SimpleImport("github.com/jinzhu/gorm/dialects/postgres"),
+			})
+		})
+	})
+}
+
+// generateRoundTripTests writes the tests converting the records of each
+// model to the types it is built from and back.  The tests of a model that
+// doesn't convert to any type anymore are removed.
+func (g *Generator) generateRoundTripTests(outdir string, api *design.APIDefinition) error {
+	return GormaDesign.IterateStores(func(store *RelationalStoreDefinition) error {
+		return store.IterateModels(func(model *RelationalModelDefinition) error {
+			filename := filepath.Join(outdir, fmt.Sprintf("%s_roundtrip_test.go", strings.ToLower(codegen.Goify(model.ModelName, false))))
+			if len(model.RoundTripTypes()) == 0 {
+				return os.RemoveAll(filename)
+			}
+			data := &UserTypeTemplateData{
+				APIDefinition: api,
+				UserType:      model,
+				DefaultPkg:    g.target,
+				AppPkg:        g.appPkgPath,
+				LegacyLists:   g.legacy,
+			}
+			return g.writeHelpers(filename, fmt.Sprintf("%s: Round Trip Tests", api.Context()), "", roundTripT, data, []*codegen.ImportSpec{
+				codegen.SimpleImport("encoding/json"),
+				codegen.SimpleImport("fmt"),
+				codegen.SimpleImport("reflect"),
+				codegen.SimpleImport("testing"),
+				codegen.SimpleImport("time"),
+				codegen.SimpleImport("github.com/gofrs/uuid"),
+				codegen.SimpleImport("github.com/jinzhu/gorm/dialects/postgres"),
+			})
+		})
+	})
+}
+
+// generateAdapters writes the conversion functions of the adapters of each
+// model.  The file of a model that doesn't declare adapters anymore is
+// removed.
+func (g *Generator) generateAdapters(outdir string, api *design.APIDefinition) error {
+	return GormaDesign.IterateStores(func(store *RelationalStoreDefinition) error {
+		return store.IterateModels(func(model *RelationalModelDefinition) error {
+			filename := filepath.Join(outdir, fmt.Sprintf("%s_adapters.go", strings.ToLower(codegen.Goify(model.ModelName, false))))
+			if len(model.Adapters()) == 0 {
+				return os.RemoveAll(filename)
+			}
+			data := &UserTypeTemplateData{
+				APIDefinition: api,
+				UserType:      model,
+				DefaultPkg:    g.target,
+				AppPkg:        g.appPkgPath,
+				LegacyLists:   g.legacy,
+			}
+			imports := []*codegen.ImportSpec{codegen.SimpleImport(g.appPkgPath)}
+			for _, path := range model.AdapterImports() {
+				imports = append(imports, codegen.SimpleImport(path))
+			}
+			return g.writeHelpers(filename, fmt.Sprintf("%s: Adapters", api.Context()), "", adapterT, data, imports)
+		})
+	})
+}
+
+// generateSeeds writes the function seeding each store declaring seeds.
+// The function of a store that doesn't anymore is removed.
+func (g *Generator) generateSeeds(outdir string, api *design.APIDefinition) error {
+	return GormaDesign.IterateStores(func(store *RelationalStoreDefinition) error {
+		filename := filepath.Join(outdir, codegen.SnakeCase(store.Name)+"_seed.go")
+		if len(store.Seeds) == 0 {
+			return os.RemoveAll(filename)
+		}
+		return g.writeHelpers(filename, fmt.Sprintf("%s: %s Seeds", api.Context(), store.Name), "", seedT, store, []*codegen.ImportSpec{
+			codegen.SimpleImport("context"),
+			codegen.SimpleImport("encoding/json"),
+			codegen.SimpleImport("time"),
+			codegen.SimpleImport("github.com/gofrs/uuid"),
+			codegen.SimpleImport("github.com/jinzhu/gorm"),
+			codegen.SimpleImport("github.com/jinzhu/gorm/dialects/postgres"),
+		})
+	})
+}
+
+// writeBuildTag writes the build constraint restricting a generated file
+// to builds with the tag, it must precede the header.
+func writeBuildTag(file *codegen.SourceFile, tag string) error {
+	_, err := fmt.Fprintf(file, "//go:build %s\n// +build %s\n\n", tag, tag)
+	return err
+}
+
+// generateTestHelpers writes the package of the test repositories, named
+// after the models package with a "test" suffix and created inside it.
+func (g *Generator) generateTestHelpers(outdir string, api *design.APIDefinition) error {
+	pkg := g.target + "test"
+	dir := filepath.Join(outdir, pkg)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	modelsPath, err := codegen.PackagePath(outdir)
+	if err != nil {
+		return err
+	}
+	err = g.writeSource(filepath.Join(dir, pkg+".go"), fmt.Sprintf("%s: Test Databases", api.Context()), pkg, "", testHelpersT, pkg, []*codegen.ImportSpec{
+		codegen.SimpleImport("context"),
+		codegen.SimpleImport("fmt"),
+		codegen.SimpleImport("sync"),
+		codegen.SimpleImport("testing"),
+		codegen.SimpleImport("github.com/gofrs/uuid"),
+		codegen.SimpleImport("github.com/jinzhu/gorm"),
+		codegen.NewImport("_", "github.com/jinzhu/gorm/dialects/sqlite"),
+	})
+	if err != nil {
+		return err
+	}
+	return GormaDesign.IterateStores(func(store *RelationalStoreDefinition) error {
+		filename := filepath.Join(dir, codegen.SnakeCase(store.RepositoryName())+".go")
+		data := map[string]interface{}{"Store": store, "Pkg": g.target}
+		return g.writeSource(filename, fmt.Sprintf("%s: %s Test Repository", api.Context(), store.Name), pkg, "", testRepositoryT, data, []*codegen.ImportSpec{
+			codegen.SimpleImport("testing"),
+			codegen.SimpleImport(modelsPath),
+		})
+	})
+}
+
+// writeHelpers writes a file of the models package from a template, built
+// only with the tag if not empty.
+func (g *Generator) writeHelpers(filename, title, tag, tmpl string, data interface{}, imports []*codegen.ImportSpec) error {
+	return g.writeSource(filename, title, g.target, tag, tmpl, data, imports)
+}
+
+// writeSource writes a file of the pkg package from a template, see
+// writeHelpers.
+func (g *Generator) writeSource(filename, title, pkg, tag, tmpl string, data interface{}, imports []*codegen.ImportSpec) error {
+	if err := os.RemoveAll(filename); err != nil {
+		fmt.Println(err)
+	}
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return err
+	}
+	if tag != "" {
+		if err := writeBuildTag(file, tag); err != nil {
+			return err
+		}
+	}
+	if err := file.WriteHeader(title, pkg, imports); err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	if err := file.ExecuteTemplate("helpers", tmpl, nil, data); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+// generateMigrations iterates through the relational stores and writes the
+// numbered up and down SQL migrations of their schema, one directory per
+// store.  The first migration creates the schema, the following ones are
+// diffed against the schema snapshot saved by the previous run.
+func (g *Generator) generateMigrations(migdir string) error {
+	if migdir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(migdir, 0755); err != nil {
+		return err
+	}
+	snapshotFile := filepath.Join(migdir, "schema.json")
+	prev, err := LoadSchemaSnapshot(snapshotFile)
+	if err != nil {
+		return err
+	}
+	current := NewSchemaSnapshot(GormaDesign)
+
+	err = GormaDesign.IterateStores(func(store *RelationalStoreDefinition) error {
+		dir := filepath.Join(migdir, store.Name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		var old *StoreSnapshot
+		if prev != nil {
+			old = prev.Stores[store.Name]
+		}
+		if old == nil {
+			// a store added to a design with a snapshot follows the
+			// migrations already in its directory, if any
+			version := 0
+			if prev != nil {
+				last, err := lastMigrationVersion(dir)
+				if err != nil {
+					return err
+				}
+				version = last
+			}
+			name := fmt.Sprintf("%04d_create_%s", version+1, codegen.SnakeCase(store.Name))
+			return g.writeMigration(dir, name, store.MigrationUp(), store.MigrationDown())
+		}
+
+		cur := current.Stores[store.Name]
+		up := old.Diff(cur)
+		if len(up) == 0 {
+			return nil
+		}
+		version, err := lastMigrationVersion(dir)
+		if err != nil {
+			return err
+		}
+		name := fmt.Sprintf("%04d_update_%s", version+1, codegen.SnakeCase(store.Name))
+		return g.writeMigration(dir, name, migrationSQL(store, up), migrationSQL(store, cur.Diff(old)))
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := current.Save(snapshotFile); err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, snapshotFile)
+	return nil
+}
+
+// writeMigration writes the up and down files of a migration.
+func (g *Generator) writeMigration(dir, name, up, down string) error {
+	upFile := filepath.Join(dir, name+".up.sql")
+	if err := ioutil.WriteFile(upFile, []byte(up), 0644); err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, upFile)
+	downFile := filepath.Join(dir, name+".down.sql")
+	if err := ioutil.WriteFile(downFile, []byte(down), 0644); err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, downFile)
+	return nil
+}
+
+// lastMigrationVersion returns the highest version number of the up
+// migrations in dir.
+func lastMigrationVersion(dir string) (int, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	var last int
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".up.sql") {
+			continue
+		}
+		var version int
+		if _, err := fmt.Sscanf(f.Name(), "%d_", &version); err == nil && version > last {
+			last = version
+		}
+	}
+	return last, nil
+}
@@ -0,0 +1,83 @@
+package gorma
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NewIndexDefinition returns an initialized IndexDefinition.
+func NewIndexDefinition() *IndexDefinition {
+	i := &IndexDefinition{
+		Descending: make(map[string]bool),
+	}
+	return i
+}
+
+// Context returns the generic definition name used in error messages.
+func (i *IndexDefinition) Context() string {
+	if i.Name != "" {
+		return fmt.Sprintf("Index %#v", i.Name)
+	}
+	return "unnamed Index"
+}
+
+// DSL returns this object's DSL.
+func (i *IndexDefinition) DSL() func() {
+	return i.DefinitionDSL
+}
+
+// IterateIndexes runs an iterator function once per Index in the Model's
+// index list.
+func (f *RelationalModelDefinition) IterateIndexes(it IndexIterator) error {
+	var names []string
+	for n := range f.Indexes {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if err := it(f.Indexes[n]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uniqueIndexName returns the name of the index created for a field
+// declared with the Unique DSL.
+func uniqueIndexName(f *RelationalFieldDefinition) string {
+	return fmt.Sprintf("uidx_%s_%s", f.Parent.DatabaseTableName(), f.ColumnName())
+}
+
+// indexTags returns the gorm `index` and `unique_index` settings of the
+// indexes the field is part of.
+func indexTags(f *RelationalFieldDefinition) []string {
+	if f.Parent == nil {
+		return nil
+	}
+	var indexes, uniques []string
+	if f.Unique {
+		uniques = append(uniques, uniqueIndexName(f))
+	}
+	f.Parent.IterateIndexes(func(i *IndexDefinition) error {
+		for _, name := range i.Fields {
+			if name != f.FieldName {
+				continue
+			}
+			if i.Unique {
+				uniques = append(uniques, i.Name)
+			} else {
+				indexes = append(indexes, i.Name)
+			}
+		}
+		return nil
+	})
+	var tags []string
+	if len(indexes) > 0 {
+		tags = append(tags, "index:"+strings.Join(indexes, ","))
+	}
+	if len(uniques) > 0 {
+		tags = append(tags, "unique_index:"+strings.Join(uniques, ","))
+	}
+	return tags
+}
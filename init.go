@@ -35,6 +35,9 @@ const (
 	Text FieldType = "text"
 	// UUID is not implemented yet
 	UUID FieldType = "uuid"
+	// JSON is a JSON document field type, stored as JSONB in Postgres
+	// and as TEXT in the other databases
+	JSON FieldType = "json"
 	// Timestamp is a date/time field in the database
 	Timestamp FieldType = "timestamp"
 	// NullableTimestamp is a timestamp that may not be
@@ -56,4 +59,36 @@ const (
 	Many2ManyKey FieldType = "many2manykey"
 	// BelongsTo is used internally
 	BelongsTo FieldType = "belongsto"
+	// AutoIncrement has the database number new records, the default for
+	// a single Integer or BigInteger primary key
+	AutoIncrement KeyGeneratorType = "autoincrement"
+	// UUIDv4 generates random UUIDs, the default for UUID primary keys
+	UUIDv4 KeyGeneratorType = "uuidv4"
+	// UUIDv7 generates time ordered UUIDs
+	UUIDv7 KeyGeneratorType = "uuidv7"
+	// ULID generates time ordered ULIDs, stored as 26 character strings
+	ULID KeyGeneratorType = "ulid"
+	// KeyFunc calls a function supplied by the application to generate
+	// keys
+	KeyFunc KeyGeneratorType = "func"
+	// FilterEq selects the records whose column equals the filter value
+	FilterEq FilterOperator = "eq"
+	// FilterNe selects the records whose column differs from the filter
+	// value
+	FilterNe FilterOperator = "ne"
+	// FilterLt selects the records whose column is lower than the filter
+	// value
+	FilterLt FilterOperator = "lt"
+	// FilterGt selects the records whose column is greater than the
+	// filter value
+	FilterGt FilterOperator = "gt"
+	// FilterIn selects the records whose column is one of the filter
+	// values
+	FilterIn FilterOperator = "in"
+	// FilterLike selects the records whose column matches the filter
+	// pattern
+	FilterLike FilterOperator = "like"
+	// FilterIsNull selects the records whose column is NULL, or not NULL
+	// when the filter value is false
+	FilterIsNull FilterOperator = "isnull"
 )
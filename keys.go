@@ -0,0 +1,139 @@
+package gorma
+
+import (
+	"fmt"
+	"strings"
+)
+
+// keyGenerator returns the way new values of a primary key field are
+// generated: the generator chosen with the KeyGenerator DSL or the default
+// of the field type.  Single integer keys are auto incremented by the
+// database, UUID keys get random UUIDs and any other key is natural, the
+// application sets it.
+func keyGenerator(f *RelationalFieldDefinition) KeyGeneratorType {
+	if !f.PrimaryKey {
+		return ""
+	}
+	if f.KeyGenerator != "" {
+		return f.KeyGenerator
+	}
+	switch f.Datatype {
+	case Integer, BigInteger, AutoInteger, AutoBigInteger:
+		if isAutoIncrement(f) {
+			return AutoIncrement
+		}
+	case UUID:
+		return UUIDv4
+	}
+	return ""
+}
+
+// zeroValue returns the Go zero value of a key type.
+func zeroValue(t FieldType) string {
+	switch t {
+	case UUID:
+		return "uuid.Nil"
+	case String, Text:
+		return `""`
+	}
+	return "0"
+}
+
+// KeyGeneratorName returns the name of the package variable holding the
+// function generating the values of a KeyFunc primary key field.
+func (f *RelationalFieldDefinition) KeyGeneratorName() string {
+	return f.Parent.ModelName + f.FieldName + "Generator"
+}
+
+// KeyGenerators returns the declarations of the package variables holding
+// the application functions generating primary keys, see KeyFunc.
+func (f *RelationalModelDefinition) KeyGenerators() string {
+	var decls []string
+	for _, pk := range f.PrimaryKeyFields() {
+		if keyGenerator(pk) != KeyFunc {
+			continue
+		}
+		decls = append(decls, fmt.Sprintf("// %s generates the %s of new %s records, Add fails when it is not set.\nvar %s func(ctx context.Context) (%s, error)\n",
+			pk.KeyGeneratorName(), pk.FieldName, f.ModelName, pk.KeyGeneratorName(), keyGoDatatype(pk)))
+	}
+	return strings.Join(decls, "\n")
+}
+
+// KeyGeneration returns the code of the Add method setting the primary keys
+// of a new record.  Keys already set are kept, keys generated by the
+// database and natural keys are left alone.
+func (f *RelationalModelDefinition) KeyGeneration() string {
+	return f.keyGeneration(false)
+}
+
+// FakeKeyGeneration is KeyGeneration for the Add method of the in-memory
+// fake storage, which stands in for the database: it also generates the
+// auto incremented keys, from the counters declared by FakeCounters, and
+// the UUIDs the database would generate.
+func (f *RelationalModelDefinition) FakeKeyGeneration() string {
+	return f.keyGeneration(true)
+}
+
+// FakeCounters returns the fields of the fake storage counting the auto
+// incremented keys.
+func (f *RelationalModelDefinition) FakeCounters() string {
+	var fields []string
+	for _, pk := range f.PrimaryKeyFields() {
+		if keyGenerator(pk) == AutoIncrement {
+			fields = append(fields, fmt.Sprintf("last%s %s", pk.FieldName, keyGoDatatype(pk)))
+		}
+	}
+	return strings.Join(fields, "\n")
+}
+
+func (f *RelationalModelDefinition) keyGeneration(fake bool) string {
+	var code []string
+	for _, pk := range f.PrimaryKeyFields() {
+		target := "model." + pk.FieldName
+		zero := zeroValue(pk.Datatype)
+		var value string
+		switch keyGenerator(pk) {
+		case AutoIncrement:
+			if !fake {
+				continue
+			}
+			counter := "f.last" + pk.FieldName
+			code = append(code, fmt.Sprintf("if %s == 0 {\n\t%s++\n\t%s = %s\n}\n", target, counter, target, counter))
+			continue
+		case UUIDv4:
+			if isGeneratedUUID(pk) && !fake {
+				code = append(code, fmt.Sprintf("// The database generates %s, gorm reads it back with RETURNING.\n", pk.FieldName))
+				continue
+			}
+			value = "uuid.Must(uuid.NewV4())"
+			if pk.Datatype == String {
+				value += ".String()"
+			}
+		case UUIDv7:
+			value = "uuid.Must(uuid.NewV7())"
+			if pk.Datatype == String {
+				value += ".String()"
+			}
+		case ULID:
+			value = "ulid.Make().String()"
+		case KeyFunc:
+			name := pk.KeyGeneratorName()
+			code = append(code, fmt.Sprintf(`if %s == %s {
+	if %s == nil {
+		return errors.New("%s is not set")
+	}
+	key, err := %s(ctx)
+	if err != nil {
+		return err
+	}
+	%s = key
+}
+`, target, zero, name, name, name, target))
+			continue
+		default:
+			continue
+		}
+		code = append(code, fmt.Sprintf("if %s == %s {\n\t%s = %s\n}\n", target, zero, target, value))
+	}
+	return strings.Join(code, "")
+}
@@ -0,0 +1,164 @@
+package gorma
+
+import (
+	"fmt"
+	"strings"
+)
+
+// columnFields returns the fields stored in the columns of the model's
+// table, keys referencing composite primary keys split in one field per
+// column.
+func (f *RelationalModelDefinition) columnFields() []*RelationalFieldDefinition {
+	var fields []*RelationalFieldDefinition
+	f.IterateFields(func(field *RelationalFieldDefinition) error {
+		if field.IsColumn() {
+			fields = append(fields, keyFields(field)...)
+		}
+		return nil
+	})
+	return fields
+}
+
+// isSortable returns true if the field may order lists by default, when
+// the model declares no Sortable field.  Keyset pagination compares the
+// values of the ordering columns, so nullable columns are left out.
+func isSortable(f *RelationalFieldDefinition) bool {
+	if f.Nullable {
+		return false
+	}
+	switch keyDatatype(f) {
+	case Boolean, Integer, BigInteger, Decimal, BigDecimal, String, UUID, Timestamp:
+		return true
+	}
+	return false
+}
+
+// sortableFields returns the fields that may order the model's lists: the
+// fields declared Sortable, or the sortable fields by default.  Primary keys
+// complete the order of every list, they are always sortable.
+func (f *RelationalModelDefinition) sortableFields() []*RelationalFieldDefinition {
+	fields := f.columnFields()
+	explicit := false
+	for _, field := range fields {
+		explicit = explicit || field.Sortable
+	}
+	var sortable []*RelationalFieldDefinition
+	for _, field := range fields {
+		if field.PrimaryKey || (explicit && field.Sortable && !field.Nullable) || (!explicit && isSortable(field)) {
+			sortable = append(sortable, field)
+		}
+	}
+	return sortable
+}
+
+// filterOps returns the operators filtering the model's lists on each
+// column field declared Filterable.  The lists can't be filtered on the
+// other fields, their columns aren't exposed to the API query parameters.
+func (f *RelationalModelDefinition) filterOps() ([]*RelationalFieldDefinition, [][]FilterOperator) {
+	var filtered []*RelationalFieldDefinition
+	var ops [][]FilterOperator
+	for _, field := range f.columnFields() {
+		if len(field.FilterOps) > 0 {
+			filtered = append(filtered, field)
+			ops = append(ops, field.FilterOps)
+		}
+	}
+	return filtered, ops
+}
+
+// filterSuffixes are appended to the field names of the filter struct
+// fields, per operator.
+var filterSuffixes = map[FilterOperator]string{
+	FilterEq:     "",
+	FilterNe:     "Ne",
+	FilterLt:     "Lt",
+	FilterGt:     "Gt",
+	FilterIn:     "In",
+	FilterLike:   "Like",
+	FilterIsNull: "IsNull",
+}
+
+// filterSQL are the conditions of the operators.
+var filterSQL = map[FilterOperator]string{
+	FilterEq:   "%s = ?",
+	FilterNe:   "%s <> ?",
+	FilterLt:   "%s < ?",
+	FilterGt:   "%s > ?",
+	FilterIn:   "%s IN (?)",
+	FilterLike: "%s LIKE ?",
+}
+
+// quotedColumn returns the column name of the field quoted for the dialect
+// of the model's store.
+func (f *RelationalModelDefinition) quotedColumn(field *RelationalFieldDefinition) string {
+	column := field.ColumnName()
+	if dialect := f.Dialect(); dialect != None {
+		column = quoteIdentifier(dialect, column)
+	}
+	return column
+}
+
+// ListKeys returns the Go expression of the primary key columns completing
+// the order of the model's lists.
+func (f *RelationalModelDefinition) ListKeys() string {
+	var keys []string
+	for _, pk := range f.PrimaryKeyFields() {
+		keys = append(keys, fmt.Sprintf("%q", pk.ColumnName()))
+	}
+	return fmt.Sprintf("[]string{%s}", strings.Join(keys, ", "))
+}
+
+// ListColumns returns the cases of the switch whitelisting the columns
+// ordering the model's lists.
+func (f *RelationalModelDefinition) ListColumns() string {
+	var cases []string
+	for _, field := range f.sortableFields() {
+		cases = append(cases, fmt.Sprintf("case %q:\n\treturn %q, &m.%s", field.ColumnName(), f.quotedColumn(field), field.FieldName))
+	}
+	return strings.Join(cases, "\n")
+}
+
+// FilterDefinition returns the fields of the model's filter struct, one per
+// field and operator: a pointer to the value compared to, a slice of values
+// for FilterIn and a pointer to a bool for FilterIsNull.
+func (f *RelationalModelDefinition) FilterDefinition() string {
+	var defs []string
+	fields, ops := f.filterOps()
+	for i, field := range fields {
+		for _, op := range ops[i] {
+			name := field.FieldName + filterSuffixes[op]
+			switch op {
+			case FilterIn:
+				defs = append(defs, fmt.Sprintf("%s []%s", name, keyGoDatatype(field)))
+			case FilterIsNull:
+				defs = append(defs, fmt.Sprintf("%s *bool", name))
+			default:
+				defs = append(defs, fmt.Sprintf("%s *%s", name, keyGoDatatype(field)))
+			}
+		}
+	}
+	return strings.Join(defs, "\n")
+}
+
+// FilterCode returns the body of the gorm scope applying the model's
+// filter struct to a query.
+func (f *RelationalModelDefinition) FilterCode() string {
+	var code []string
+	fields, ops := f.filterOps()
+	for i, field := range fields {
+		column := f.quotedColumn(field)
+		for _, op := range ops[i] {
+			name := "filter." + field.FieldName + filterSuffixes[op]
+			switch op {
+			case FilterIn:
+				code = append(code, fmt.Sprintf("if len(%s) > 0 {\n\tdb = db.Where(%q, %s)\n}", name, fmt.Sprintf(filterSQL[op], column), name))
+			case FilterIsNull:
+				code = append(code, fmt.Sprintf("if %s != nil {\n\tif *%s {\n\t\tdb = db.Where(%q)\n\t} else {\n\t\tdb = db.Where(%q)\n\t}\n}",
+					name, name, column+" IS NULL", column+" IS NOT NULL"))
+			default:
+				code = append(code, fmt.Sprintf("if %s != nil {\n\tdb = db.Where(%q, *%s)\n}", name, fmt.Sprintf(filterSQL[op], column), name))
+			}
+		}
+	}
+	return strings.Join(code, "\n")
+}
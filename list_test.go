@@ -0,0 +1,78 @@
+package gorma_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Gys/gorma"
+)
+
+func TestListColumns(t *testing.T) {
+	m := makeModel(makeStore(gorma.Postgres), "User")
+	makeField(m, "Name", gorma.String)
+	makeField(m, "Bio", gorma.Text)
+	makeField(m, "Nickname", gorma.String).Nullable = true
+
+	exp := "case \"id\":\n\treturn \"\\\"id\\\"\", &m.ID\ncase \"name\":\n\treturn \"\\\"name\\\"\", &m.Name"
+	if cols := m.ListColumns(); cols != exp {
+		t.Errorf("expected %q, got %q", exp, cols)
+	}
+	if keys := m.ListKeys(); keys != `[]string{"id"}` {
+		t.Errorf("unexpected list keys %s", keys)
+	}
+}
+
+func TestFilterDefinition(t *testing.T) {
+	m := makeModel(makeStore(gorma.MySQL), "User")
+	makeField(m, "Bio", gorma.Text)
+	nickname := makeField(m, "Nickname", gorma.String)
+	nickname.Nullable = true
+	if def := m.FilterDefinition(); def != "" {
+		t.Errorf("expected no filter without Filterable fields, got %q", def)
+	}
+	m.RelationalFields["ID"].FilterOps = []gorma.FilterOperator{gorma.FilterEq}
+	nickname.FilterOps = []gorma.FilterOperator{gorma.FilterEq}
+
+	exp := "ID *int\nNickname *string"
+	if def := m.FilterDefinition(); def != exp {
+		t.Errorf("expected %q, got %q", exp, def)
+	}
+	exp = "if filter.ID != nil {\n\tdb = db.Where(\"`id` = ?\", *filter.ID)\n}\nif filter.Nickname != nil {\n\tdb = db.Where(\"`nickname` = ?\", *filter.Nickname)\n}"
+	if code := m.FilterCode(); code != exp {
+		t.Errorf("expected %q, got %q", exp, code)
+	}
+}
+
+func TestListColumnsSortable(t *testing.T) {
+	m := makeModel(makeStore(gorma.Postgres), "User")
+	makeField(m, "Name", gorma.String)
+	makeField(m, "Rank", gorma.Integer).Sortable = true
+
+	exp := "case \"id\":\n\treturn \"\\\"id\\\"\", &m.ID\ncase \"rank\":\n\treturn \"\\\"rank\\\"\", &m.Rank"
+	if cols := m.ListColumns(); cols != exp {
+		t.Errorf("expected %q, got %q", exp, cols)
+	}
+}
+
+func TestFilterOperators(t *testing.T) {
+	m := makeModel(makeStore(gorma.Postgres), "User")
+	makeField(m, "Rank", gorma.Integer)
+	name := makeField(m, "Name", gorma.String)
+	name.Nullable = true
+	name.FilterOps = []gorma.FilterOperator{gorma.FilterIn, gorma.FilterLike, gorma.FilterIsNull}
+
+	exp := "NameIn []string\nNameLike *string\nNameIsNull *bool"
+	if def := m.FilterDefinition(); def != exp {
+		t.Errorf("expected %q, got %q", exp, def)
+	}
+	code := m.FilterCode()
+	for _, exp := range []string{
+		"if len(filter.NameIn) > 0 {\n\tdb = db.Where(\"\\\"name\\\" IN (?)\", filter.NameIn)\n}",
+		"db = db.Where(\"\\\"name\\\" LIKE ?\", *filter.NameLike)",
+		"if *filter.NameIsNull {\n\t\tdb = db.Where(\"\\\"name\\\" IS NULL\")\n\t} else {\n\t\tdb = db.Where(\"\\\"name\\\" IS NOT NULL\")",
+	} {
+		if !strings.Contains(code, exp) {
+			t.Errorf("expected %q in %q", exp, code)
+		}
+	}
+}
@@ -1,7 +1,68 @@
 package gorma
 
+import "github.com/Gys/goa/dslengine"
+
 // NewMapDefinition returns an initialized
 // MapDefinition.
 func NewMapDefinition() *MapDefinition {
 	return &MapDefinition{}
 }
+
+// AddMapping maps the field to an attribute of a goa type, see MapsFrom
+// and MapsTo.  Mappings to unknown attributes or to attributes another
+// field of the model is mapped to are reported as errors.  The field the
+// model created for the attribute when it was built from the type is
+// removed: the mapped field takes its place and its validations.
+func (f *RelationalFieldDefinition) AddMapping(md *MapDefinition) {
+	name := md.RemoteType.TypeName
+	att, ok := md.RemoteType.ToObject()[md.RemoteField]
+	if !ok {
+		dslengine.ReportError("field %s maps to %s, which is not an attribute of type %s", f.FieldName, md.RemoteField, name)
+		return
+	}
+	if f.Parent != nil {
+		if other := f.Parent.mappedField(name, md.RemoteField); other != nil && other != f {
+			dslengine.ReportError("fields %s and %s both map to attribute %s of type %s", other.FieldName, f.FieldName, md.RemoteField, name)
+			return
+		}
+	}
+	f.Mappings[name] = md
+	if f.Parent == nil {
+		return
+	}
+	for fname, other := range f.Parent.RelationalFields {
+		if other != f && other.a == att {
+			delete(f.Parent.RelationalFields, fname)
+		}
+	}
+	if f.Validation == nil {
+		f.populateValidation(att)
+	}
+}
+
+// mappedField returns the field of the model explicitly mapped to the
+// attribute of the type named typeName, nil if there is none.
+func (f *RelationalModelDefinition) mappedField(typeName, attribute string) *RelationalFieldDefinition {
+	var mapped *RelationalFieldDefinition
+	f.IterateFields(func(field *RelationalFieldDefinition) error {
+		if md, ok := field.Mappings[typeName]; ok && md.RemoteField == attribute && mapped == nil {
+			mapped = field
+		}
+		return nil
+	})
+	return mapped
+}
+
+// mapsTo returns true if the field is converted from or to the attribute
+// of the type named typeName: the attribute it is mapped to if any,
+// otherwise the attribute named after the field unless another field is
+// mapped to it.
+func (f *RelationalFieldDefinition) mapsTo(typeName, attribute string) bool {
+	if md, ok := f.Mappings[typeName]; ok {
+		return md.RemoteField == attribute
+	}
+	if f.Underscore() != attribute && f.DatabaseFieldName != attribute {
+		return false
+	}
+	return f.Parent == nil || f.Parent.mappedField(typeName, attribute) == nil
+}
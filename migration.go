@@ -0,0 +1,202 @@
+package gorma
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Gys/goa/goagen/codegen"
+)
+
+// CreateTableSQL returns the CREATE TABLE statement for the model, in the
+// dialect of its store.
+func (f *RelationalModelDefinition) CreateTableSQL() string {
+	return f.Snapshot().createSQL(f.Dialect())
+}
+
+// foreignKeys returns the FOREIGN KEY constraints for the model's
+// relationship key fields in the dialect.
+func (f *RelationalModelDefinition) foreignKeys(dialect RelationalStorageType) []*ForeignKeySnapshot {
+	var fks []*ForeignKeySnapshot
+	f.IterateFields(func(field *RelationalFieldDefinition) error {
+		if !isRelationshipKey(field) && !f.isParentKey(field) {
+			return nil
+		}
+		rm := relatedModel(field)
+		if rm == nil {
+			return nil
+		}
+		var columns, refColumns []string
+		if keys := keyFields(field); len(keys) > 1 {
+			// composite key, one column per primary key of the parent
+			for i, pk := range rm.PrimaryKeyFields() {
+				columns = append(columns, keys[i].ColumnName())
+				refColumns = append(refColumns, pk.ColumnName())
+			}
+		} else {
+			refColumn := "id"
+			if pk := relatedPrimaryKey(field); pk != nil {
+				refColumn = pk.ColumnName()
+			}
+			columns = []string{field.ColumnName()}
+			refColumns = []string{refColumn}
+		}
+		fks = append(fks, foreignKey(dialect, f.DatabaseTableName(), columns, rm.DatabaseTableName(), refColumns))
+		return nil
+	})
+	return fks
+}
+
+// foreignKey returns the constraint of the columns of the table
+// referencing the columns of the refTable, named after the table and the
+// columns.
+func foreignKey(dialect RelationalStorageType, table string, columns []string, refTable string, refColumns []string) *ForeignKeySnapshot {
+	quote := func(names []string) string {
+		var quoted []string
+		for _, name := range names {
+			quoted = append(quoted, quoteIdentifier(dialect, name))
+		}
+		return strings.Join(quoted, ", ")
+	}
+	return &ForeignKeySnapshot{
+		Name: foreignKeyName(table, columns),
+		Definition: fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)",
+			quote(columns), quoteIdentifier(dialect, refTable), quote(refColumns)),
+	}
+}
+
+// CreateIndexSQL returns the CREATE INDEX statements for the model's fields
+// tagged with gorm's `index` or `unique_index` settings.
+func (f *RelationalModelDefinition) CreateIndexSQL() []string {
+	var stmts []string
+	for _, i := range f.indexes() {
+		stmts = append(stmts, i.createSQL(f.Dialect(), f.DatabaseTableName()))
+	}
+	return stmts
+}
+
+// DropTableSQL returns the DROP TABLE statement for the model.
+func (f *RelationalModelDefinition) DropTableSQL() string {
+	return fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", quoteIdentifier(f.Dialect(), f.DatabaseTableName()))
+}
+
+// joinColumn returns the join table column referencing the model, following
+// gorm's <model>_<pk> convention, along with the referenced primary key
+// column and its type.
+func joinColumn(m *RelationalModelDefinition, dialect RelationalStorageType) (string, string, string) {
+	pkColumn := "id"
+	datatype := sqlDatatype(&RelationalFieldDefinition{Datatype: Integer}, dialect)
+	if pks := m.PrimaryKeyFields(); len(pks) == 1 {
+		pkColumn = pks[0].ColumnName()
+		datatype = sqlDatatype(pks[0], dialect)
+	}
+	return codegen.SnakeCase(m.ModelName) + "_" + pkColumn, pkColumn, datatype
+}
+
+// resolve returns the store's definition of a model referenced by a
+// relationship, which may have been declared before the model itself.
+func (m *ManyToManyDefinition) resolve(model *RelationalModelDefinition) *RelationalModelDefinition {
+	if m.Left != nil && m.Left.Parent != nil {
+		if rm, ok := m.Left.Parent.RelationalModels[model.ModelName]; ok {
+			return rm
+		}
+	}
+	return model
+}
+
+// CreateTableSQL returns the CREATE TABLE statement for the join table of
+// the relationship.
+func (m *ManyToManyDefinition) CreateTableSQL() string {
+	return m.Snapshot().createSQL(m.Left.Dialect())
+}
+
+// DropTableSQL returns the DROP TABLE statement for the join table of the
+// relationship.
+func (m *ManyToManyDefinition) DropTableSQL() string {
+	return fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", quoteIdentifier(m.Left.Dialect(), m.DatabaseField))
+}
+
+// IterateModelsByDependency runs an iterator function once per Model in the
+// Store's model list, visiting the models a Model references through its
+// foreign keys before the Model itself.
+func (sd *RelationalStoreDefinition) IterateModelsByDependency(it ModelIterator) error {
+	var ordered []*RelationalModelDefinition
+	visited := make(map[string]bool)
+	var visit func(m *RelationalModelDefinition)
+	visit = func(m *RelationalModelDefinition) {
+		if visited[m.ModelName] {
+			return
+		}
+		visited[m.ModelName] = true
+		m.IterateFields(func(field *RelationalFieldDefinition) error {
+			if !isRelationshipKey(field) && !m.isParentKey(field) {
+				return nil
+			}
+			if rm := relatedModel(field); rm != nil && rm != m {
+				if _, ok := sd.RelationalModels[rm.ModelName]; ok {
+					visit(sd.RelationalModels[rm.ModelName])
+				}
+			}
+			return nil
+		})
+		ordered = append(ordered, m)
+	}
+	sd.IterateModels(func(m *RelationalModelDefinition) error {
+		visit(m)
+		return nil
+	})
+	for _, m := range ordered {
+		if err := it(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// joinTables returns the many to many relationships of the store, one per
+// join table.
+func (sd *RelationalStoreDefinition) joinTables() []*ManyToManyDefinition {
+	var m2ms []*ManyToManyDefinition
+	seen := make(map[string]bool)
+	sd.IterateModels(func(m *RelationalModelDefinition) error {
+		var keys []string
+		for k := range m.ManyToMany {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			m2m := m.ManyToMany[k]
+			if seen[m2m.DatabaseField] {
+				continue
+			}
+			seen[m2m.DatabaseField] = true
+			m2ms = append(m2ms, m2m)
+		}
+		return nil
+	})
+	return m2ms
+}
+
+// MigrationUp returns the DDL creating the tables, indexes and join tables
+// of every model in the store.
+func (sd *RelationalStoreDefinition) MigrationUp() string {
+	return migrationSQL(sd, (&StoreSnapshot{}).Diff(sd.Snapshot()))
+}
+
+// MigrationDown returns the DDL dropping everything MigrationUp creates, in
+// reverse order.
+func (sd *RelationalStoreDefinition) MigrationDown() string {
+	return migrationSQL(sd, sd.Snapshot().Diff(&StoreSnapshot{Name: sd.Name, Type: sd.Type}))
+}
+
+// migrationSQL returns the content of a migration file for the store.
+func migrationSQL(sd *RelationalStoreDefinition, stmts []string) string {
+	return fmt.Sprintf("-- Code generated by gorma, DO NOT EDIT.\n-- Store: %s (%s)\n\n", sd.Name, sd.Type) + strings.Join(stmts, "\n")
+}
+
+// SQLiteSchema returns the statements creating the tables, indexes and join
+// tables of the store in a SQLite database, whatever the dialect of the
+// store: the generated test helpers run the stores against SQLite.
+func (sd *RelationalStoreDefinition) SQLiteSchema() []string {
+	return (&StoreSnapshot{}).Diff(sd.snapshot(SQLite3))
+}
@@ -0,0 +1,240 @@
+package gorma_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Gys/gorma"
+	"github.com/Gys/gorma/dsl"
+)
+
+func makeStore(dialect gorma.RelationalStorageType) *gorma.RelationalStoreDefinition {
+	sd := gorma.NewRelationalStoreDefinition()
+	sd.Name = "test"
+	sd.Type = dialect
+	return sd
+}
+
+func makeModel(sd *gorma.RelationalStoreDefinition, name string) *gorma.RelationalModelDefinition {
+	m := gorma.NewRelationalModelDefinition()
+	m.ModelName = name
+	m.Parent = sd
+	sd.RelationalModels[name] = m
+	id := makeField(m, "ID", gorma.Integer)
+	id.PrimaryKey = true
+	return m
+}
+
+func makeField(m *gorma.RelationalModelDefinition, name string, datatype gorma.FieldType) *gorma.RelationalFieldDefinition {
+	f := gorma.NewRelationalFieldDefinition()
+	f.FieldName = dsl.SanitizeFieldName(name)
+	f.DatabaseFieldName = dsl.SanitizeDBFieldName(f.FieldName)
+	f.Datatype = datatype
+	f.Parent = m
+	m.RelationalFields[f.FieldName] = f
+	return f
+}
+
+func TestCreateTableSQL(t *testing.T) {
+	var tests = []struct {
+		dialect  gorma.RelationalStorageType
+		expected string
+	}{
+		{gorma.Postgres, "CREATE TABLE \"users\" (\n\t\"id\" SERIAL,\n\t\"name\" VARCHAR(255) NULL,\n\tPRIMARY KEY (\"id\")\n);\n"},
+		{gorma.MySQL, "CREATE TABLE `users` (\n\t`id` INT NOT NULL AUTO_INCREMENT,\n\t`name` VARCHAR(255) NULL,\n\tPRIMARY KEY (`id`)\n);\n"},
+		{gorma.SQLite3, "CREATE TABLE \"users\" (\n\t\"id\" INTEGER PRIMARY KEY AUTOINCREMENT,\n\t\"name\" VARCHAR(255) NULL\n);\n"},
+	}
+	for _, tt := range tests {
+		m := makeModel(makeStore(tt.dialect), "User")
+		makeField(m, "Name", gorma.String).Nullable = true
+		sql := m.CreateTableSQL()
+		if sql != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.dialect, tt.expected, sql)
+		}
+	}
+}
+
+func TestCreateTableSQLForeignKey(t *testing.T) {
+	sd := makeStore(gorma.Postgres)
+	user := makeModel(sd, "User")
+	order := makeModel(sd, "Order")
+	makeField(order, "UserID", gorma.BelongsTo)
+	order.BelongsTo["User"] = user
+
+	sql := order.CreateTableSQL()
+	exp := "\"user_id\" INTEGER NOT NULL"
+	if !strings.Contains(sql, exp) {
+		t.Errorf("Expected %s in %s", exp, sql)
+	}
+	exp = "CONSTRAINT \"fk_orders_user_id\" FOREIGN KEY (\"user_id\") REFERENCES \"users\" (\"id\")"
+	if !strings.Contains(sql, exp) {
+		t.Errorf("Expected %s in %s", exp, sql)
+	}
+}
+
+func TestCreateIndexSQL(t *testing.T) {
+	m := makeModel(makeStore(gorma.Postgres), "User")
+	makeField(m, "Email", gorma.String).SQLTag = "unique_index"
+	makeField(m, "FirstName", gorma.String).SQLTag = "index:idx_name"
+	makeField(m, "LastName", gorma.String).SQLTag = "index:idx_name"
+
+	stmts := m.CreateIndexSQL()
+	exp := []string{
+		"CREATE INDEX \"idx_name\" ON \"users\" (\"first_name\", \"last_name\");\n",
+		"CREATE UNIQUE INDEX \"uidx_users_email\" ON \"users\" (\"email\");\n",
+	}
+	if len(stmts) != len(exp) {
+		t.Fatalf("Expected %d statements, got %d", len(exp), len(stmts))
+	}
+	for i := range exp {
+		if stmts[i] != exp[i] {
+			t.Errorf("Expected %q, got %q", exp[i], stmts[i])
+		}
+	}
+}
+
+func TestMigrationOrder(t *testing.T) {
+	sd := makeStore(gorma.SQLite3)
+	user := makeModel(sd, "User")
+	account := makeModel(sd, "Account")
+	makeField(account, "UserID", gorma.BelongsTo)
+	account.BelongsTo["User"] = user
+
+	up := sd.MigrationUp()
+	if strings.Index(up, "CREATE TABLE \"users\"") > strings.Index(up, "CREATE TABLE \"accounts\"") {
+		t.Errorf("Expected users to be created before accounts, got %s", up)
+	}
+	down := sd.MigrationDown()
+	if strings.Index(down, "DROP TABLE IF EXISTS \"users\"") < strings.Index(down, "DROP TABLE IF EXISTS \"accounts\"") {
+		t.Errorf("Expected accounts to be dropped before users, got %s", down)
+	}
+}
+
+func TestCreateIndexSQLDefinitions(t *testing.T) {
+	m := makeModel(makeStore(gorma.Postgres), "Order")
+	makeField(m, "UserID", gorma.Integer)
+	makeField(m, "Email", gorma.String).Unique = true
+	makeField(m, "ShippedAt", gorma.Timestamp)
+	i := gorma.NewIndexDefinition()
+	i.Name = "idx_orders_user"
+	i.Parent = m
+	i.Fields = []string{"UserID", "ShippedAt"}
+	i.Descending["ShippedAt"] = true
+	i.Condition = "shipped_at IS NOT NULL"
+	m.Indexes[i.Name] = i
+
+	stmts := m.CreateIndexSQL()
+	exp := []string{
+		"CREATE INDEX \"idx_orders_user\" ON \"orders\" (\"user_id\", \"shipped_at\" DESC) WHERE shipped_at IS NOT NULL;\n",
+		"CREATE UNIQUE INDEX \"uidx_orders_email\" ON \"orders\" (\"email\");\n",
+	}
+	if len(stmts) != len(exp) {
+		t.Fatalf("Expected %d statements, got %q", len(exp), stmts)
+	}
+	for i := range exp {
+		if stmts[i] != exp[i] {
+			t.Errorf("Expected %q, got %q", exp[i], stmts[i])
+		}
+	}
+	if err := i.Validate(); err != nil {
+		t.Errorf("Expected a valid index, got %s", err)
+	}
+
+	i.Fields = append(i.Fields, "Total")
+	if err := i.Validate(); err == nil {
+		t.Errorf("Expected an error for the unknown field Total")
+	}
+}
+
+func TestCreateTableSQLConstraints(t *testing.T) {
+	m := makeModel(makeStore(gorma.Postgres), "Order")
+	f := makeField(m, "Status", gorma.String)
+	f.Default = "pending"
+	f.Check = "status <> ''"
+	f.Description = "fulfilment status"
+
+	sql := m.CreateTableSQL()
+	for _, exp := range []string{
+		"\"status\" VARCHAR(255) NOT NULL DEFAULT 'pending'",
+		"CONSTRAINT \"chk_orders_status\" CHECK (status <> '')",
+		"COMMENT ON COLUMN \"orders\".\"status\" IS 'fulfilment status';\n",
+	} {
+		if !strings.Contains(sql, exp) {
+			t.Errorf("Expected %s in %s", exp, sql)
+		}
+	}
+
+	m = makeModel(makeStore(gorma.MySQL), "Order")
+	f = makeField(m, "Paid", gorma.Boolean)
+	f.Default = false
+	f.Description = "it's paid"
+	exp := "`paid` BOOLEAN NOT NULL DEFAULT FALSE COMMENT 'it''s paid'"
+	if sql := m.CreateTableSQL(); !strings.Contains(sql, exp) {
+		t.Errorf("Expected %s in %s", exp, sql)
+	}
+}
+
+func TestCreateTableSQLCompositeForeignKey(t *testing.T) {
+	sd := makeStore(gorma.Postgres)
+	membership := makeModel(sd, "Membership")
+	delete(membership.RelationalFields, "ID")
+	user := makeField(membership, "UserID", gorma.Integer)
+	group := makeField(membership, "GroupID", gorma.Integer)
+	membership.PrimaryKeys = []*gorma.RelationalFieldDefinition{user, group}
+	grant := makeModel(sd, "Grant")
+	makeField(grant, "MembershipID", gorma.BelongsTo)
+	grant.BelongsTo["Membership"] = membership
+
+	sql := grant.CreateTableSQL()
+	for _, exp := range []string{
+		"\"membership_user_id\" INTEGER NOT NULL",
+		"\"membership_group_id\" INTEGER NOT NULL",
+		"CONSTRAINT \"fk_grants_membership_user_id_membership_group_id\" FOREIGN KEY (\"membership_user_id\", \"membership_group_id\") REFERENCES \"memberships\" (\"user_id\", \"group_id\")",
+	} {
+		if !strings.Contains(sql, exp) {
+			t.Errorf("Expected %s in %s", exp, sql)
+		}
+	}
+	def := grant.StructDefinition()
+	for _, exp := range []string{
+		"MembershipUserID\tint",
+		"MembershipGroupID\tint",
+		"Membership\tMembership `gorm:\"foreignkey:MembershipUserID,MembershipGroupID;association_foreignkey:UserID,GroupID\"`",
+	} {
+		if !strings.Contains(def, exp) {
+			t.Errorf("Expected %s in %s", exp, def)
+		}
+	}
+	if where := grant.BelongsToWhere("Membership"); where != "\"membership_user_id\" = ? and \"membership_group_id\" = ?" {
+		t.Errorf("Unexpected where clause %s", where)
+	}
+	if key := membership.CacheKey("m"); key != "fmt.Sprint(m.UserID, \"/\", m.GroupID)" {
+		t.Errorf("Unexpected cache key %s", key)
+	}
+}
+
+func TestSQLiteSchema(t *testing.T) {
+	sd := makeStore(gorma.MySQL)
+	user := makeModel(sd, "User")
+	makeField(user, "Token", gorma.UUID)
+
+	schema := sd.SQLiteSchema()
+	if len(schema) != 1 {
+		t.Fatalf("expected 1 statement, got %v", schema)
+	}
+	exp := "CREATE TABLE \"users\" (\n\t\"id\" INTEGER PRIMARY KEY AUTOINCREMENT,\n\t\"token\" CHAR(36) NOT NULL\n);\n"
+	if schema[0] != exp {
+		t.Errorf("expected %q, got %q", exp, schema[0])
+	}
+	if sd.Type != gorma.MySQL {
+		t.Errorf("expected the store to stay %s, got %s", gorma.MySQL, sd.Type)
+	}
+
+	sd = makeStore(gorma.Postgres)
+	makeModel(sd, "Session").RelationalFields["ID"].Datatype = gorma.UUID
+	schema = sd.SQLiteSchema()
+	exp = "CREATE TABLE \"sessions\" (\n\t\"id\" CHAR(36) NOT NULL,\n\tPRIMARY KEY (\"id\")\n);\n"
+	if len(schema) != 1 || schema[0] != exp {
+		t.Errorf("expected %q, got %q", exp, schema)
+	}
+}
@@ -2,6 +2,7 @@ package gorma
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"unicode"
 
@@ -43,13 +44,63 @@ func (f *RelationalFieldDefinition) Attribute() *design.AttributeDefinition {
 	return f.a
 }
 
+// populateValidation copies the validations of the goa attribute the field
+// is built from.  The maximum length of a string attribute becomes the size
+// of the column.
+func (f *RelationalFieldDefinition) populateValidation(att *design.AttributeDefinition) {
+	if att.Validation == nil {
+		return
+	}
+	f.Validation = att.Validation
+	if _, ok := sqlTagSettings(f.SQLTag)["SIZE"]; ok || f.Size > 0 {
+		return
+	}
+	if f.Datatype == String && att.Validation.MaxLength != nil {
+		f.Size = *att.Validation.MaxLength
+	}
+}
+
+// validationAttribute returns an attribute carrying the field's validations
+// for the goa validation code generator, nil if the field has none or if
+// its type can't be validated.
+func (f *RelationalFieldDefinition) validationAttribute() *design.AttributeDefinition {
+	if f.Validation == nil {
+		return nil
+	}
+	var t design.DataType
+	switch f.Datatype {
+	case Boolean:
+		t = design.Boolean
+	case Integer, BigInteger:
+		t = design.Integer
+	case Decimal, BigDecimal:
+		t = design.Number
+	case String, Text:
+		t = design.String
+	default:
+		return nil
+	}
+	return &design.AttributeDefinition{Type: t, Validation: f.Validation}
+}
+
 // FieldDefinition returns the field's struct definition.
 func (f *RelationalFieldDefinition) FieldDefinition() string {
 	var comment string
 	if f.Description != "" {
 		comment = "// " + f.Description
 	}
-	def := fmt.Sprintf("%s\t%s %s %s\n", f.FieldName, goDatatype(f, true), tags(f), comment)
+	if keys := keyFields(f); len(keys) > 1 {
+		var defs []string
+		for _, key := range keys {
+			defs = append(defs, key.FieldDefinition())
+		}
+		return strings.Join(defs, "")
+	}
+	tag := tags(f)
+	if at := fieldAssociationTag(f); at != "" {
+		tag = at
+	}
+	def := fmt.Sprintf("%s\t%s %s %s\n", f.FieldName, goDatatype(f, true), tag, comment)
 	return def
 }
 
@@ -63,6 +114,24 @@ func (f *RelationalFieldDefinition) LowerName() string {
 	return strings.ToLower(f.FieldName)
 }
 
+// ColumnName returns the name of the database column backing the field.
+func (f *RelationalFieldDefinition) ColumnName() string {
+	if f.DatabaseFieldName != "" {
+		return f.DatabaseFieldName
+	}
+	return f.Underscore()
+}
+
+// IsColumn returns true if the field is stored in a column of its model's
+// table.  Relationship fields that live in other tables return false.
+func (f *RelationalFieldDefinition) IsColumn() bool {
+	switch f.Datatype {
+	case "", NotFound, HasOne, HasMany, Many2Many, Many2ManyKey:
+		return false
+	}
+	return f.Many2Many == ""
+}
+
 // Underscore returns the field name as a lowercase string in snake case.
 func (f *RelationalFieldDefinition) Underscore() string {
 	runes := []rune(f.FieldName)
@@ -103,6 +172,11 @@ func goDatatype(f *RelationalFieldDefinition, includePtr bool) string {
 		return ptr + "string"
 	case UUID:
 		return ptr + "uuid.UUID"
+	case JSON:
+		if f.Dialect() == Postgres {
+			return ptr + "postgres.Jsonb"
+		}
+		return ptr + "json.RawMessage"
 	case Timestamp, NullableTimestamp:
 		return ptr + "time.Time"
 	case BelongsTo:
@@ -134,44 +208,61 @@ func goDatatypeByModel(m *RelationalModelDefinition, belongsToModelName string)
 }
 
 func belongsToIDType(f *RelationalFieldDefinition, includePtr bool) string {
-	if f.Parent == nil {
-		return "int"
-	}
-	modelName := strings.Replace(f.FieldName, "ID", "", -1)
-	model := f.Parent.BelongsTo[modelName]
-	return relatedIDType(model, includePtr)
+	return relatedIDType(relatedModel(f), includePtr)
 }
 
 func hasOneIDType(f *RelationalFieldDefinition, includePtr bool) string {
-	if f.Parent == nil {
+	return relatedIDType(relatedModel(f), includePtr)
+}
+
+func hasManyIDType(f *RelationalFieldDefinition, includePtr bool) string {
+	return relatedIDType(relatedModel(f), includePtr)
+}
+
+// relatedIDType returns the Go type of a key referencing the model.  Keys
+// referencing a composite primary key are split into one field per key
+// column by keyFields, each typed after its own column.
+func relatedIDType(m *RelationalModelDefinition, includePtr bool) string {
+	if m == nil {
 		return "int"
 	}
-	modelName := strings.Replace(f.FieldName, "ID", "", -1)
-	model := f.Parent.HasOne[modelName]
-	return relatedIDType(model, includePtr)
+	pks := m.PrimaryKeyFields()
+	if len(pks) != 1 {
+		return "int"
+	}
+	return goDatatype(pks[0], includePtr)
 }
 
-func hasManyIDType(f *RelationalFieldDefinition, includePtr bool) string {
+// relatedModel returns the model a foreign key field points to.
+func relatedModel(f *RelationalFieldDefinition) *RelationalModelDefinition {
 	if f.Parent == nil {
-		return "int"
+		return nil
+	}
+	modelName := strings.TrimSuffix(f.FieldName, "ID")
+	if f.Parent.Parent != nil {
+		if m, ok := f.Parent.Parent.RelationalModels[modelName]; ok {
+			return m
+		}
 	}
-	modelName := strings.Replace(f.FieldName, "ID", "", -1)
-	model := f.Parent.HasMany[modelName]
-	return relatedIDType(model, includePtr)
+	return f.Parent.BelongsTo[modelName]
 }
 
-func relatedIDType(m *RelationalModelDefinition, includePtr bool) string {
+// relatedPrimaryKey returns the primary key field a foreign key field
+// references, or nil if it can't be determined.
+func relatedPrimaryKey(f *RelationalFieldDefinition) *RelationalFieldDefinition {
+	m := relatedModel(f)
 	if m == nil {
-		return "int"
+		return nil
 	}
-	if len(m.PrimaryKeys) > 1 {
-		panic("Can't determine field Type when using multiple primary keys")
+	pks := m.PrimaryKeyFields()
+	if len(pks) != 1 {
+		return nil
 	}
-	return goDatatype(m.PrimaryKeys[0], includePtr)
+	return pks[0]
 }
 
 func tags(f *RelationalFieldDefinition) string {
-	var sqltags []string
+	sqltags := append(sqlTypeTags(f), sqlConstraintTags(f)...)
 	if f.SQLTag != "" {
 		sqltags = append(sqltags, f.SQLTag)
 	}
@@ -183,13 +274,14 @@ func tags(f *RelationalFieldDefinition) string {
 	if f.PrimaryKey {
 		gormtags = append(gormtags, "primary_key")
 	}
+	gormtags = append(gormtags, indexTags(f)...)
 	if f.Many2Many != "" {
 		gormtags = append(gormtags, "many2many:"+f.TableName)
 	}
 
 	var tags []string
 	if len(sqltags) > 0 {
-		sqltag := "sql:\"" + strings.Join(sqltags, ";") + "\""
+		sqltag := "sql:" + strconv.Quote(strings.Join(sqltags, ";"))
 		tags = append(tags, sqltag)
 	}
 	if len(gormtags) > 0 {
@@ -2,6 +2,7 @@ package gorma_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/Gys/gorma"
@@ -78,3 +79,40 @@ func TestFieldDefinitions(t *testing.T) {
 	}
 
 }
+
+func TestFieldDefinitionsDialect(t *testing.T) {
+	var fieldtests = []struct {
+		dialect  gorma.RelationalStorageType
+		datatype gorma.FieldType
+		expected string
+	}{
+		{gorma.Postgres, gorma.UUID, "Token\tuuid.UUID `sql:\"type:uuid\"` \n"},
+		{gorma.MySQL, gorma.UUID, "Token\tuuid.UUID `sql:\"type:char(36)\"` \n"},
+		{gorma.Postgres, gorma.JSON, "Token\tpostgres.Jsonb `sql:\"type:jsonb\"` \n"},
+		{gorma.SQLite3, gorma.JSON, "Token\tjson.RawMessage `sql:\"type:text\"` \n"},
+		{gorma.MySQL, gorma.Text, "Token\tstring `sql:\"type:text\"` \n"},
+		{gorma.MySQL, gorma.String, "Token\tstring  \n"},
+	}
+	for _, tt := range fieldtests {
+		m := makeModel(makeStore(tt.dialect), "User")
+		def := makeField(m, "Token", tt.datatype).FieldDefinition()
+		if def != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.dialect, tt.expected, def)
+		}
+	}
+}
+
+func TestFieldDefinitionsGeneratedUUID(t *testing.T) {
+	m := makeModel(makeStore(gorma.Postgres), "User")
+	m.RelationalFields["ID"].Datatype = gorma.UUID
+
+	def := m.RelationalFields["ID"].FieldDefinition()
+	exp := "ID\tuuid.UUID `sql:\"type:uuid;default:gen_random_uuid()\" gorm:\"primary_key\"` \n"
+	if def != exp {
+		t.Errorf("expected %q, got %q", exp, def)
+	}
+	exp = "\"id\" UUID NOT NULL DEFAULT gen_random_uuid()"
+	if sql := m.CreateTableSQL(); !strings.Contains(sql, exp) {
+		t.Errorf("Expected %s in %s", exp, sql)
+	}
+}
@@ -23,13 +23,18 @@ func NewRelationalModelDefinition() *RelationalModelDefinition {
 	}
 	utd.Type = design.Object{}
 	m := &RelationalModelDefinition{
-		RelationalFields: make(map[string]*RelationalFieldDefinition),
-		BuiltFrom:        make(map[string]*design.UserTypeDefinition),
-		RenderTo:         make(map[string]*design.MediaTypeDefinition),
-		BelongsTo:        make(map[string]*RelationalModelDefinition),
-		HasMany:          make(map[string]*RelationalModelDefinition),
-		HasOne:           make(map[string]*RelationalModelDefinition),
-		ManyToMany:       make(map[string]*ManyToManyDefinition),
+		RelationalFields:  make(map[string]*RelationalFieldDefinition),
+		BuiltFrom:         make(map[string]*design.UserTypeDefinition),
+		RenderTo:          make(map[string]*design.MediaTypeDefinition),
+		Renders:           make(map[string]*RenderDefinition),
+		BelongsTo:         make(map[string]*RelationalModelDefinition),
+		HasMany:           make(map[string]*RelationalModelDefinition),
+		HasOne:            make(map[string]*RelationalModelDefinition),
+		ManyToMany:        make(map[string]*ManyToManyDefinition),
+		Indexes:           make(map[string]*IndexDefinition),
+		MediaTypeAdapters: make(map[string]*MediaTypeAdapterDefinition),
+		UserTypeAdapters:  make(map[string]*UserTypeAdapterDefinition),
+		PayloadAdapters:   make(map[string]*PayloadAdapterDefinition),
 		UserTypeDefinition: &design.UserTypeDefinition{
 			AttributeDefinition: baseAttr,
 		},
@@ -55,12 +60,24 @@ func (f RelationalModelDefinition) TableName() string {
 	return inflect.Underscore(inflection.Plural(f.ModelName))
 }
 
+// DatabaseTableName returns the name of the model's table in the database,
+// honoring the Alias.
+func (f *RelationalModelDefinition) DatabaseTableName() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.TableName()
+}
+
 // Children returns a slice of this objects children.
 func (f RelationalModelDefinition) Children() []dslengine.Definition {
 	var stores []dslengine.Definition
 	for _, s := range f.RelationalFields {
 		stores = append(stores, s)
 	}
+	for _, i := range f.Indexes {
+		stores = append(stores, i)
+	}
 	return stores
 }
 
@@ -68,18 +85,40 @@ func (f RelationalModelDefinition) Children() []dslengine.Definition {
 // useful for method parameters.
 func (f *RelationalModelDefinition) PKAttributes() string {
 	var attr []string
-	for _, pk := range f.PrimaryKeys {
+	for _, pk := range f.PrimaryKeyFields() {
 		attr = append(attr, fmt.Sprintf("%s %s", codegen.Goify(pk.DatabaseFieldName, false), goDatatype(pk, true)))
 	}
 	return strings.Join(attr, ",")
 }
 
+// PrimaryKeyFields returns the primary key fields of the model.  Fields
+// declared with the PrimaryKey DSL take precedence over the fields flagged
+// as primary keys, like the automatic ID field.
+func (f *RelationalModelDefinition) PrimaryKeyFields() []*RelationalFieldDefinition {
+	if len(f.PrimaryKeys) > 0 {
+		return f.PrimaryKeys
+	}
+	var pks []*RelationalFieldDefinition
+	f.IterateFields(func(field *RelationalFieldDefinition) error {
+		if field.PrimaryKey {
+			pks = append(pks, field)
+		}
+		return nil
+	})
+	return pks
+}
+
 // PKWhere returns an array of strings representing the where clause
-// of a retrieval by primary key(s) -- x = ? and y = ?.
+// of a retrieval by primary key(s) -- x = ? and y = ?.  Column names are
+// quoted for the dialect of the model's store.
 func (f *RelationalModelDefinition) PKWhere() string {
 	var pkwhere []string
-	for _, pk := range f.PrimaryKeys {
-		def := fmt.Sprintf("%s = ?", pk.DatabaseFieldName)
+	for _, pk := range f.PrimaryKeyFields() {
+		column := pk.DatabaseFieldName
+		if dialect := f.Dialect(); dialect != None {
+			column = quoteIdentifier(dialect, column)
+		}
+		def := fmt.Sprintf("%s = ?", column)
 		pkwhere = append(pkwhere, def)
 	}
 	return strings.Join(pkwhere, " and ")
@@ -89,18 +128,39 @@ func (f *RelationalModelDefinition) PKWhere() string {
 // keys of a model.
 func (f *RelationalModelDefinition) PKWhereFields() string {
 	var pkwhere []string
-	for _, pk := range f.PrimaryKeys {
+	for _, pk := range f.PrimaryKeyFields() {
 		def := fmt.Sprintf("%s", codegen.Goify(pk.DatabaseFieldName, false))
 		pkwhere = append(pkwhere, def)
 	}
 	return strings.Join(pkwhere, ",")
 }
 
+// CacheKey returns the expression of the cache key of a model built from
+// all of its primary keys: the fields of the named model variable, or the
+// primary key parameters built by PKAttributes when the name is empty.
+func (f *RelationalModelDefinition) CacheKey(modelname string) string {
+	var keys []string
+	for _, pk := range f.PrimaryKeyFields() {
+		if modelname == "" {
+			keys = append(keys, codegen.Goify(pk.DatabaseFieldName, false))
+		} else {
+			keys = append(keys, fmt.Sprintf("%s.%s", modelname, codegen.Goify(pk.FieldName, true)))
+		}
+	}
+	return fmt.Sprintf("fmt.Sprint(%s)", strings.Join(keys, `, "/", `))
+}
+
+// StorageError returns the expression mapping the error variable to the
+// storage errors for the dialect of the model's store.
+func (f *RelationalModelDefinition) StorageError(err string) string {
+	return fmt.Sprintf("storageError(%q, %q, %s)", f.Dialect(), f.ModelName, err)
+}
+
 // PKUpdateFields returns something?  This function doesn't look useful in
 // current form.  Perhaps it isn't.
 func (f *RelationalModelDefinition) PKUpdateFields(modelname string) string {
 	var pkwhere []string
-	for _, pk := range f.PrimaryKeys {
+	for _, pk := range f.PrimaryKeyFields() {
 		def := fmt.Sprintf("%s.%s", modelname, codegen.Goify(pk.FieldName, true))
 		pkwhere = append(pkwhere, def)
 	}
@@ -126,12 +186,37 @@ func (f *RelationalModelDefinition) StructDefinition() string {
 	sort.Strings(keys)
 
 	for _, k := range keys {
-		output = output + f.BelongsTo[k].ModelName + "\t" + f.BelongsTo[k].ModelName + "\n"
+		bt := f.BelongsTo[k]
+		output = output + bt.ModelName + "\t" + bt.ModelName
+		if tag := associationTag(f.storeModel(bt.ModelName), f); tag != "" {
+			output = output + " " + tag
+		}
+		output = output + "\n"
 	}
 	footer := "}\n"
 	return header + output + footer
 }
 
+// ValidationCode returns the body of the model's Validate method: the goa
+// validations of the fields built from payload attributes.  The code sets
+// the err variable.
+func (f *RelationalModelDefinition) ValidationCode() string {
+	var checks []string
+	f.IterateFields(func(field *RelationalFieldDefinition) error {
+		att := field.validationAttribute()
+		if att == nil {
+			return nil
+		}
+		context := fmt.Sprintf("%s.%s", f.LowerName(), field.ColumnName())
+		code := codegen.ValidationChecker(att, false, !field.Nullable, false, "m."+field.FieldName, context, 1, false)
+		if code != "" {
+			checks = append(checks, code)
+		}
+		return nil
+	})
+	return strings.Join(checks, "\n")
+}
+
 // Attribute implements the Container interface of goa.
 func (f *RelationalModelDefinition) Attribute() *design.AttributeDefinition {
 	return f.AttributeDefinition
@@ -219,9 +304,13 @@ func (f *RelationalModelDefinition) IterateFields(it FieldIterator) error {
 	fields = append(fields, namekeys...)
 	fields = append(fields, datekeys...)
 
-	// Iterate them
+	// Iterate them, the iterator may remove fields (see AddMapping)
 	for _, n := range fields {
-		if err := it(f.RelationalFields[n]); err != nil {
+		field, ok := f.RelationalFields[n]
+		if !ok {
+			continue
+		}
+		if err := it(field); err != nil {
 			return err
 		}
 	}
@@ -240,10 +329,17 @@ func (f *RelationalModelDefinition) PopulateFromModeledType() {
 	for _, utd := range f.BuiltFrom {
 		obj := utd.ToObject()
 		obj.IterateAttributes(func(name string, att *design.AttributeDefinition) error {
+			if mf := f.mappedField(utd.TypeName, name); mf != nil {
+				if mf.Validation == nil {
+					mf.populateValidation(att)
+				}
+				return nil
+			}
 			rf, ok := f.RelationalFields[codegen.Goify(name, true)]
 			if ok {
 				// We already have a mapping for this field.  What to do?
 				if rf.Datatype != "" {
+					rf.populateValidation(att)
 					return nil
 				}
 				// we may have seen the field but don't know its type
@@ -304,6 +400,7 @@ func (f *RelationalModelDefinition) PopulateFromModeledType() {
 			}
 			// might need this later?
 			rf.a = att
+			rf.populateValidation(att)
 			f.RelationalFields[rf.FieldName] = rf
 
 			addAttributeToModel(name, att, f)
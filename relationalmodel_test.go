@@ -2,9 +2,11 @@ package gorma_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/Gys/goa/design"
+	"github.com/Gys/goa/dslengine"
 	"github.com/Gys/gorma"
 	"github.com/Gys/gorma/dsl"
 )
@@ -128,6 +130,26 @@ func TestPKWhereMultiple(t *testing.T) {
 
 }
 
+func TestPKWhereQuoted(t *testing.T) {
+	var tests = []struct {
+		dialect  gorma.RelationalStorageType
+		expected string
+	}{
+		{gorma.Postgres, "\"id\" = ?"},
+		{gorma.MySQL, "`id` = ?"},
+		{gorma.SQLite3, "\"id\" = ?"},
+	}
+	for _, tt := range tests {
+		sg := makeModel(makeStore(tt.dialect), "User")
+		sg.PrimaryKeys = append(sg.PrimaryKeys, sg.RelationalFields["ID"])
+
+		pkw := sg.PKWhere()
+		if pkw != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.dialect, tt.expected, pkw)
+		}
+	}
+}
+
 func TestPKWhereFieldsSingle(t *testing.T) {
 	sg := &gorma.RelationalModelDefinition{}
 	sg.RelationalFields = make(map[string]*gorma.RelationalFieldDefinition)
@@ -221,3 +243,109 @@ func TestTableName(t *testing.T) {
 		t.Errorf("Expected %s, got %s", "users", sg.TableName())
 	}
 }
+
+func TestPopulateValidations(t *testing.T) {
+	m := makeModel(makeStore(gorma.Postgres), "User")
+	min, maxLength := 18.0, 120
+	payload := &design.UserTypeDefinition{
+		AttributeDefinition: &design.AttributeDefinition{
+			Type: design.Object{
+				"email": &design.AttributeDefinition{
+					Type:       design.String,
+					Validation: &dslengine.ValidationDefinition{MaxLength: &maxLength},
+				},
+				"age": &design.AttributeDefinition{
+					Type:       design.Integer,
+					Validation: &dslengine.ValidationDefinition{Minimum: &min},
+				},
+			},
+			Validation: &dslengine.ValidationDefinition{Required: []string{"email", "age"}},
+		},
+		TypeName: "UserPayload",
+	}
+	m.BuiltFrom["UserPayload"] = payload
+	m.PopulateFromModeledType()
+
+	if size := m.RelationalFields["Email"].Size; size != maxLength {
+		t.Errorf("Expected size %d, got %d", maxLength, size)
+	}
+	sql := m.CreateTableSQL()
+	for _, exp := range []string{
+		"\"email\" VARCHAR(120) NOT NULL",
+		"CONSTRAINT \"chk_users_age\" CHECK (\"age\" >= 18)",
+	} {
+		if !strings.Contains(sql, exp) {
+			t.Errorf("Expected %s in %s", exp, sql)
+		}
+	}
+	code := m.ValidationCode()
+	exp := "goa.InvalidRangeError(`user.age`, m.Age, 18, true)"
+	if !strings.Contains(code, exp) {
+		t.Errorf("Expected %s in %s", exp, code)
+	}
+}
+
+func TestKeyGeneration(t *testing.T) {
+	var tests = []struct {
+		dialect   gorma.RelationalStorageType
+		datatype  gorma.FieldType
+		generator gorma.KeyGeneratorType
+		expected  string
+	}{
+		{gorma.Postgres, gorma.Integer, "", ""},
+		{gorma.Postgres, gorma.String, "", ""},
+		{gorma.Postgres, gorma.UUID, "", "// The database generates ID, gorm reads it back with RETURNING.\n"},
+		{gorma.MySQL, gorma.UUID, "", "if model.ID == uuid.Nil {\n\tmodel.ID = uuid.Must(uuid.NewV4())\n}\n"},
+		{gorma.Postgres, gorma.UUID, gorma.UUIDv7, "if model.ID == uuid.Nil {\n\tmodel.ID = uuid.Must(uuid.NewV7())\n}\n"},
+		{gorma.MySQL, gorma.String, gorma.UUIDv4, "if model.ID == \"\" {\n\tmodel.ID = uuid.Must(uuid.NewV4()).String()\n}\n"},
+		{gorma.MySQL, gorma.String, gorma.ULID, "if model.ID == \"\" {\n\tmodel.ID = ulid.Make().String()\n}\n"},
+	}
+	for _, tt := range tests {
+		m := makeModel(makeStore(tt.dialect), "User")
+		m.RelationalFields["ID"].Datatype = tt.datatype
+		m.RelationalFields["ID"].KeyGenerator = tt.generator
+		if code := m.KeyGeneration(); code != tt.expected {
+			t.Errorf("%s %s %q: expected %q, got %q", tt.dialect, tt.datatype, tt.generator, tt.expected, code)
+		}
+	}
+}
+
+func TestKeyFunc(t *testing.T) {
+	m := makeModel(makeStore(gorma.MySQL), "User")
+	m.RelationalFields["ID"].Datatype = gorma.String
+	m.RelationalFields["ID"].KeyGenerator = gorma.KeyFunc
+
+	if decl := m.KeyGenerators(); !strings.Contains(decl, "var UserIDGenerator func(ctx context.Context) (string, error)") {
+		t.Errorf("expected the generator variable, got %q", decl)
+	}
+	exp := "if UserIDGenerator == nil {\n\t\treturn errors.New(\"UserIDGenerator is not set\")\n\t}"
+	if code := m.KeyGeneration(); !strings.Contains(code, exp) {
+		t.Errorf("expected %q in %q", exp, code)
+	}
+	if m.RelationalFields["ID"].FieldDefinition() != "ID\tstring `gorm:\"primary_key\"` \n" {
+		t.Errorf("unexpected field definition %q", m.RelationalFields["ID"].FieldDefinition())
+	}
+}
+
+func TestStorageError(t *testing.T) {
+	m := makeModel(makeStore(gorma.MySQL), "User")
+	exp := `storageError("mysql", "User", err)`
+	if code := m.StorageError("err"); code != exp {
+		t.Errorf("expected %s, got %s", exp, code)
+	}
+}
+
+func TestModelValidate(t *testing.T) {
+	m := makeModel(makeStore(gorma.Postgres), "User")
+	if _, ok := interface{}(m).(dslengine.Validate); !ok {
+		t.Fatal("Expected the DSL engine to validate the model")
+	}
+	if err := m.Validate(); err != nil {
+		t.Errorf("Expected a valid model, got %s", err)
+	}
+
+	makeField(m, "Role", gorma.String).Parent = nil
+	if err := m.Validate(); err == nil || !strings.Contains(err.Error(), "field Role has no relational model parent") {
+		t.Errorf("Expected an error for the field without a parent, got %v", err)
+	}
+}
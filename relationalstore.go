@@ -5,6 +5,7 @@ import (
 	"sort"
 
 	"github.com/Gys/goa/dslengine"
+	"github.com/Gys/goa/goagen/codegen"
 )
 
 // NewRelationalStoreDefinition returns an initialized
@@ -38,6 +39,18 @@ func (sd RelationalStoreDefinition) Children() []dslengine.Definition {
 	return stores
 }
 
+// RepositoryName returns the name of the type holding the storages of the
+// store's models.
+func (sd *RelationalStoreDefinition) RepositoryName() string {
+	return codegen.Goify(sd.Name, true) + "Repository"
+}
+
+// StorageName returns the name of the interface giving access to the
+// storages of the store's models.
+func (sd *RelationalStoreDefinition) StorageName() string {
+	return codegen.Goify(sd.Name, true) + "Storage"
+}
+
 // IterateModels runs an iterator function once per Model in the Store's model list.
 func (sd *RelationalStoreDefinition) IterateModels(it ModelIterator) error {
 	names := make([]string, len(sd.RelationalModels))
@@ -37,3 +37,23 @@ func TestStoreDSL(t *testing.T) {
 		t.Errorf("Expected %T, got nil", f)
 	}
 }
+
+func TestStoreRepositoryName(t *testing.T) {
+	sg := &gorma.RelationalStoreDefinition{}
+	sg.Name = "read_replica"
+
+	exp := "ReadReplicaRepository"
+	if name := sg.RepositoryName(); name != exp {
+		t.Errorf("Expected %s, got %s", exp, name)
+	}
+}
+
+func TestStoreStorageName(t *testing.T) {
+	sg := &gorma.RelationalStoreDefinition{}
+	sg.Name = "postgres"
+
+	exp := "PostgresStorage"
+	if name := sg.StorageName(); name != exp {
+		t.Errorf("Expected %s, got %s", exp, name)
+	}
+}
@@ -0,0 +1,220 @@
+package gorma
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Gys/goa/goagen/codegen"
+)
+
+// isRelationshipKey returns true if the field holds the key of a related
+// model.
+func isRelationshipKey(f *RelationalFieldDefinition) bool {
+	switch f.Datatype {
+	case BelongsTo, HasOneKey, HasManyKey:
+		return true
+	}
+	return false
+}
+
+// isParentKey returns true if the field holds the key of a parent the
+// model belongs to, declared with Field like the primary keys of a join
+// model.
+func (f *RelationalModelDefinition) isParentKey(field *RelationalFieldDefinition) bool {
+	for _, bt := range f.BelongsTo {
+		if field.FieldName == bt.ModelName+"ID" {
+			return true
+		}
+	}
+	return false
+}
+
+// keyFields returns the fields stored in the columns of a field.  A key
+// referencing a model with a composite primary key stands for one field per
+// primary key of that model, named after the model and the key: a
+// Membership keyed by UserID and GroupID is referenced by MembershipUserID
+// and MembershipGroupID.  Any other field stands for itself.
+func keyFields(f *RelationalFieldDefinition) []*RelationalFieldDefinition {
+	if !isRelationshipKey(f) {
+		return []*RelationalFieldDefinition{f}
+	}
+	rm := relatedModel(f)
+	if rm == nil {
+		return []*RelationalFieldDefinition{f}
+	}
+	pks := rm.PrimaryKeyFields()
+	if len(pks) < 2 {
+		return []*RelationalFieldDefinition{f}
+	}
+	keys := make([]*RelationalFieldDefinition, len(pks))
+	for i, pk := range pks {
+		key := &RelationalFieldDefinition{
+			Parent:            f.Parent,
+			FieldName:         rm.ModelName + pk.FieldName,
+			DatabaseFieldName: codegen.SnakeCase(rm.ModelName) + "_" + pk.ColumnName(),
+			Datatype:          keyDatatype(pk),
+			Description:       f.Description,
+			Nullable:          f.Nullable,
+			Sortable:          f.Sortable,
+			FilterOps:         f.FilterOps,
+		}
+		if key.Datatype == String {
+			key.Size = fieldSize(pk)
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+// keyDatatype returns the type of a column referencing the primary key
+// field.
+func keyDatatype(pk *RelationalFieldDefinition) FieldType {
+	switch pk.Datatype {
+	case AutoInteger:
+		return Integer
+	case AutoBigInteger:
+		return BigInteger
+	case BelongsTo, HasOneKey, HasManyKey:
+		if rpk := relatedPrimaryKey(pk); rpk != nil && rpk != pk {
+			return keyDatatype(rpk)
+		}
+		return Integer
+	}
+	return pk.Datatype
+}
+
+// foreignKeyFields returns the fields of the model holding the key of the
+// parent model it belongs to.
+func (f *RelationalModelDefinition) foreignKeyFields(parent string) []*RelationalFieldDefinition {
+	if field, ok := f.RelationalFields[parent+"ID"]; ok {
+		return keyFields(field)
+	}
+	return []*RelationalFieldDefinition{{
+		Parent:            f,
+		FieldName:         parent + "ID",
+		DatabaseFieldName: codegen.SnakeCase(parent) + "_id",
+		Datatype:          Integer,
+	}}
+}
+
+// BelongsToAttributes constructs the parameters identifying a parent of the
+// model, one per column of the foreign key.
+func (f *RelationalModelDefinition) BelongsToAttributes(parent string) string {
+	var attr []string
+	for _, key := range f.foreignKeyFields(parent) {
+		attr = append(attr, fmt.Sprintf("%s %s", codegen.Goify(key.FieldName, false), keyGoDatatype(key)))
+	}
+	return strings.Join(attr, ", ")
+}
+
+// BelongsToFields returns the names of the parameters built by
+// BelongsToAttributes.
+func (f *RelationalModelDefinition) BelongsToFields(parent string) string {
+	var fields []string
+	for _, key := range f.foreignKeyFields(parent) {
+		fields = append(fields, codegen.Goify(key.FieldName, false))
+	}
+	return strings.Join(fields, ", ")
+}
+
+// BelongsToWhere returns the where clause selecting the children of a
+// parent of the model.  Column names are quoted for the dialect of the
+// model's store.
+func (f *RelationalModelDefinition) BelongsToWhere(parent string) string {
+	var where []string
+	for _, key := range f.foreignKeyFields(parent) {
+		column := key.ColumnName()
+		if dialect := f.Dialect(); dialect != None {
+			column = quoteIdentifier(dialect, column)
+		}
+		where = append(where, fmt.Sprintf("%s = ?", column))
+	}
+	return strings.Join(where, " and ")
+}
+
+// BelongsToGuard returns the condition under which the parameters built by
+// BelongsToAttributes identify a parent: none of them holds its zero value.
+func (f *RelationalModelDefinition) BelongsToGuard(parent string) string {
+	var guards []string
+	for _, key := range f.foreignKeyFields(parent) {
+		name := codegen.Goify(key.FieldName, false)
+		guards = append(guards, name+" != "+zeroValue(keyDatatype(key)))
+	}
+	return strings.Join(guards, " && ")
+}
+
+// keyGoDatatype returns the Go type of a key field, without pointer.
+func keyGoDatatype(key *RelationalFieldDefinition) string {
+	return strings.TrimSpace(goDatatype(key, false))
+}
+
+// associationTag returns the gorm tag of a BelongsTo, HasOne or HasMany
+// association field when the parent has a composite primary key: gorm
+// needs the foreign key and association key fields spelled out.
+func associationTag(parent, child *RelationalModelDefinition) string {
+	if parent == nil || child == nil {
+		return ""
+	}
+	pks := parent.PrimaryKeyFields()
+	if len(pks) < 2 {
+		return ""
+	}
+	var fks, aks []string
+	for i, key := range child.foreignKeyFields(parent.ModelName) {
+		fks = append(fks, key.FieldName)
+		aks = append(aks, pks[i].FieldName)
+	}
+	return fmt.Sprintf("`gorm:\"foreignkey:%s;association_foreignkey:%s\"`", strings.Join(fks, ","), strings.Join(aks, ","))
+}
+
+// fieldAssociationTag returns the association tag of a HasOne or HasMany
+// field, see associationTag.
+func fieldAssociationTag(f *RelationalFieldDefinition) string {
+	if f.Parent == nil {
+		return ""
+	}
+	var name string
+	switch f.Datatype {
+	case HasOne:
+		name = f.HasOne
+	case HasMany:
+		name = f.HasMany
+	default:
+		return ""
+	}
+	return associationTag(f.Parent, f.Parent.storeModel(name))
+}
+
+// storeModel returns the definition of a related model from the model's
+// store, relationships may refer to models declared later.
+func (f *RelationalModelDefinition) storeModel(name string) *RelationalModelDefinition {
+	if f.Parent != nil {
+		if m, ok := f.Parent.RelationalModels[name]; ok {
+			return m
+		}
+	}
+	if m, ok := f.BelongsTo[name]; ok {
+		return m
+	}
+	if m, ok := f.HasMany[name]; ok {
+		return m
+	}
+	return f.HasOne[name]
+}
+
+// conversionFields returns the fields of the model sorted by name, keys
+// referencing composite primary keys split in one field per column as they
+// are in the model struct: the fields converted from and to goa types.
+func (f *RelationalModelDefinition) conversionFields() []*RelationalFieldDefinition {
+	var names []string
+	for name := range f.RelationalFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var fields []*RelationalFieldDefinition
+	for _, name := range names {
+		fields = append(fields, keyFields(f.RelationalFields[name])...)
+	}
+	return fields
+}
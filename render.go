@@ -0,0 +1,154 @@
+package gorma
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Gys/goa/design"
+	"github.com/Gys/goa/dslengine"
+	"github.com/jinzhu/inflection"
+)
+
+// Context returns the generic definition name used in error messages.
+func (r *RenderDefinition) Context() string {
+	if r.MediaType != nil {
+		return fmt.Sprintf("RendersTo %#v", r.MediaType.TypeName)
+	}
+	return "unnamed RendersTo"
+}
+
+// DSL returns this object's DSL.
+func (r *RenderDefinition) DSL() func() {
+	return r.DefinitionDSL
+}
+
+// Validate tests whether the rendering is consistent: the views exist in
+// the media type and the preloaded relationships in the model.
+func (r *RenderDefinition) Validate() error {
+	verr := new(dslengine.ValidationErrors)
+	for _, name := range r.Views {
+		if _, ok := r.MediaType.Views[name]; !ok {
+			verr.Add(r, "media type %s has no view %s", r.MediaType.TypeName, name)
+		}
+	}
+	views := r.views()
+	for _, view := range sortedViewNames(r.Preloads) {
+		if _, ok := views[view]; view != "" && !ok {
+			verr.Add(r, "relationships preloaded by view %s, which isn't rendered", view)
+		}
+		for _, rel := range r.Preloads[view] {
+			if !r.Parent.isRelationship(rel) {
+				verr.Add(r, "model %s has no relationship %s to preload", r.Parent.ModelName, rel)
+			}
+		}
+	}
+	if err := verr.AsError(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// views returns the rendered views of the media type by name.
+func (r *RenderDefinition) views() map[string]*design.ViewDefinition {
+	if len(r.Views) == 0 {
+		return r.MediaType.Views
+	}
+	views := make(map[string]*design.ViewDefinition, len(r.Views))
+	for _, name := range r.Views {
+		if view, ok := r.MediaType.Views[name]; ok {
+			views[name] = view
+		}
+	}
+	return views
+}
+
+// RenderedViews returns the views of the media type named typeName the
+// model is rendered to: the views selected with Views, all of them by
+// default.
+func (f *RelationalModelDefinition) RenderedViews(typeName string) map[string]*design.ViewDefinition {
+	if r, ok := f.Renders[typeName]; ok {
+		return r.views()
+	}
+	if mt, ok := f.RenderTo[typeName]; ok {
+		return mt.Views
+	}
+	return nil
+}
+
+// ViewPreloads returns the relationships the model preloads when rendered
+// to the view of the media type named typeName, in declaration order.
+func (f *RelationalModelDefinition) ViewPreloads(typeName, view string) []string {
+	r, ok := f.Renders[typeName]
+	if !ok {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var preloads []string
+	for _, rel := range append(r.Preloads[""], r.Preloads[view]...) {
+		if !seen[rel] {
+			seen[rel] = true
+			preloads = append(preloads, rel)
+		}
+	}
+	return preloads
+}
+
+// ViewColumns returns the quoted, comma separated columns the queries
+// building the view of a media type select, see viewFields.  It returns an
+// empty string when the view needs no column.
+func (f *RelationalModelDefinition) ViewColumns(v *design.ViewDefinition) string {
+	var columns []string
+	for _, field := range viewFields(f, v) {
+		columns = append(columns, f.quotedColumn(field))
+	}
+	return strings.Join(columns, ", ")
+}
+
+// isRelationship returns true if the model has a relationship with the
+// name, the name of its field in the model struct.
+func (f *RelationalModelDefinition) isRelationship(name string) bool {
+	if _, ok := f.BelongsTo[name]; ok {
+		return true
+	}
+	if field, ok := f.RelationalFields[name]; ok {
+		switch field.Datatype {
+		case HasOne, HasMany, Many2Many:
+			return true
+		}
+	}
+	return false
+}
+
+// onePreloads returns the relationships the One method of a model loads
+// for every view: its has many and belongs to relationships.
+func (f *RelationalModelDefinition) onePreloads() map[string]bool {
+	preloads := make(map[string]bool)
+	for _, hm := range f.HasMany {
+		preloads[inflection.Plural(hm.ModelName)] = true
+	}
+	for _, bt := range f.BelongsTo {
+		preloads[bt.ModelName] = true
+	}
+	return preloads
+}
+
+// sortedViewNames returns the sorted keys of the preloads by view.
+func sortedViewNames(preloads map[string][]string) []string {
+	var names []string
+	for name := range preloads {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedRenderNames returns the sorted keys of the renderings of a model.
+func sortedRenderNames(renders map[string]*RenderDefinition) []string {
+	var names []string
+	for name := range renders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,69 @@
+package gorma_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Gys/goa/design"
+	"github.com/Gys/gorma"
+)
+
+func TestRenderedViews(t *testing.T) {
+	sd := makeStore(gorma.Postgres)
+	user := makeModel(sd, "User")
+	mt := &design.MediaTypeDefinition{
+		UserTypeDefinition: &design.UserTypeDefinition{TypeName: "UserMedia"},
+		Views: map[string]*design.ViewDefinition{
+			"default": {Name: "default"},
+			"tiny":    {Name: "tiny"},
+		},
+	}
+	user.RenderTo[mt.TypeName] = mt
+	if views := user.RenderedViews("UserMedia"); len(views) != 2 {
+		t.Errorf("expected all the views, got %v", views)
+	}
+
+	user.Renders[mt.TypeName] = &gorma.RenderDefinition{
+		Parent:    user,
+		MediaType: mt,
+		Views:     []string{"default"},
+		Preloads:  map[string][]string{"": {"Orders"}, "default": {"Team", "Orders"}},
+	}
+	views := user.RenderedViews("UserMedia")
+	if _, ok := views["default"]; !ok || len(views) != 1 {
+		t.Errorf("expected the default view, got %v", views)
+	}
+	if preloads := user.ViewPreloads("UserMedia", "default"); !reflect.DeepEqual(preloads, []string{"Orders", "Team"}) {
+		t.Errorf("unexpected preloads %v", preloads)
+	}
+	if preloads := user.ViewPreloads("OtherMedia", "default"); preloads != nil {
+		t.Errorf("unexpected preloads %v", preloads)
+	}
+}
+
+func TestViewColumns(t *testing.T) {
+	sd := makeStore(gorma.Postgres)
+	user := makeModel(sd, "User")
+	order := makeModel(sd, "Order")
+	makeField(order, "Note", gorma.String)
+	makeField(order, "Total", gorma.Integer)
+	makeField(order, "Placed", gorma.Timestamp).Sortable = true
+	makeField(order, "UserID", gorma.BelongsTo)
+	order.BelongsTo["User"] = user
+	mt := &design.MediaTypeDefinition{
+		UserTypeDefinition: &design.UserTypeDefinition{TypeName: "OrderMedia"},
+	}
+	view := &design.ViewDefinition{
+		AttributeDefinition: &design.AttributeDefinition{Type: design.Object{
+			"note":  {Type: design.String},
+			"links": {Type: design.String},
+		}},
+		Name:   "tiny",
+		Parent: mt,
+	}
+
+	exp := `"id", "note", "placed", "user_id"`
+	if cols := order.ViewColumns(view); cols != exp {
+		t.Errorf("expected %q, got %q", exp, cols)
+	}
+}
@@ -0,0 +1,134 @@
+package gorma
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Gys/goa/design"
+	"github.com/Gys/goa/goagen/codegen"
+)
+
+// payloadConversion is a column of a model converted to and from an
+// attribute of a type the model is built from.
+type payloadConversion struct {
+	field     *RelationalFieldDefinition
+	attribute string
+	pointer   bool // the attribute is a pointer in the type
+}
+
+// payloadConversions returns the columns of the model converted to and
+// from the primitive attributes of the type, in field name order.
+func (f *RelationalModelDefinition) payloadConversions(ut *design.UserTypeDefinition) []*payloadConversion {
+	obj := ut.ToObject()
+	var keys []string
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var conversions []*payloadConversion
+	for _, field := range f.columnFields() {
+		if keyDatatype(field) == JSON {
+			continue
+		}
+		for _, key := range keys {
+			if !obj[key].Type.IsPrimitive() || !field.mapsTo(ut.TypeName, key) {
+				continue
+			}
+			conversions = append(conversions, &payloadConversion{
+				field:     field,
+				attribute: key,
+				pointer:   ut.Definition().IsPrimitivePointer(key),
+			})
+			break
+		}
+	}
+	return conversions
+}
+
+// fieldAssignmentModelToPayload returns the code setting the attributes of
+// the type held by the utype variable from the fields of the model held by
+// the v variable: the reverse of fieldAssignmentPayloadToModel.  Values
+// are copied, except pointers shared by the model and the type.
+func fieldAssignmentModelToPayload(model *RelationalModelDefinition, ut *design.UserTypeDefinition, v, utype string) string {
+	var code []string
+	for i, c := range model.payloadConversions(ut) {
+		att := codegen.Goify(c.attribute, true)
+		switch {
+		case c.pointer && !c.field.Nullable:
+			code = append(code,
+				fmt.Sprintf("tmp%d := %s.%s", i+1, v, c.field.FieldName),
+				fmt.Sprintf("%s.%s = &tmp%d", utype, att, i+1))
+		case !c.pointer && c.field.Nullable:
+			code = append(code,
+				fmt.Sprintf("if %s.%s != nil {", v, c.field.FieldName),
+				fmt.Sprintf("\t%s.%s = *%s.%s", utype, att, v, c.field.FieldName),
+				"}")
+		default:
+			code = append(code, fmt.Sprintf("%s.%s = %s.%s", utype, att, v, c.field.FieldName))
+		}
+	}
+	return strings.Join(code, "\n")
+}
+
+// HasPayloadConversion returns true if the model generates the conversion
+// to the type it is built from named typeName: the conversion to a media
+// type the model renders to is the one of the media type.
+func (f *RelationalModelDefinition) HasPayloadConversion(typeName string) bool {
+	_, rendered := f.RenderTo[typeName]
+	_, built := f.BuiltFrom[typeName]
+	return built && !rendered
+}
+
+// RoundTripTypes returns the types the model is built from and converted
+// to with fields surviving a round trip, sorted by name.
+func (f *RelationalModelDefinition) RoundTripTypes() []*design.UserTypeDefinition {
+	var names []string
+	for name := range f.BuiltFrom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var types []*design.UserTypeDefinition
+	for _, name := range names {
+		if f.HasPayloadConversion(name) && len(f.RoundTripFields(f.BuiltFrom[name])) > 0 {
+			types = append(types, f.BuiltFrom[name])
+		}
+	}
+	return types
+}
+
+// RoundTripFields returns the names of the fields of the model converted
+// to and from the type, which survive a round trip.
+func (f *RelationalModelDefinition) RoundTripFields(ut *design.UserTypeDefinition) []string {
+	var names []string
+	for _, c := range f.payloadConversions(ut) {
+		names = append(names, c.field.FieldName)
+	}
+	return names
+}
+
+// RoundTripValues returns the code setting the nullable fields of the
+// record m converted to and from the type, which the factory leaves nil:
+// the conversion from the type would set their default.
+func (f *RelationalModelDefinition) RoundTripValues(ut *design.UserTypeDefinition) string {
+	var code []string
+	sequence := false
+	for _, c := range f.payloadConversions(ut) {
+		if !c.field.Nullable {
+			continue
+		}
+		value, seq := factoryValue(c.field)
+		if value == "" {
+			continue
+		}
+		sequence = sequence || seq
+		v := fmt.Sprintf("v%d", len(code)/2+1)
+		code = append(code,
+			fmt.Sprintf("%s := %s", v, value),
+			fmt.Sprintf("m.%s = &%s", c.field.FieldName, v))
+	}
+	if sequence {
+		code = append([]string{"n := 1"}, code...)
+	}
+	return strings.Join(code, "\n")
+}
@@ -0,0 +1,48 @@
+package gorma_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Gys/goa/design"
+	"github.com/Gys/goa/dslengine"
+	"github.com/Gys/gorma"
+)
+
+func TestRoundTrip(t *testing.T) {
+	sd := makeStore(gorma.Postgres)
+	user := makeModel(sd, "User")
+	makeField(user, "Email", gorma.String)
+	makeField(user, "Age", gorma.Integer).Nullable = true
+	makeField(user, "Password", gorma.String)
+	makeField(user, "Meta", gorma.JSON)
+	payload := &design.UserTypeDefinition{
+		TypeName: "UserPayload",
+		AttributeDefinition: &design.AttributeDefinition{
+			Type: design.Object{
+				"email":   &design.AttributeDefinition{Type: design.String},
+				"age":     &design.AttributeDefinition{Type: design.Integer},
+				"meta":    &design.AttributeDefinition{Type: design.Any},
+				"friends": &design.AttributeDefinition{Type: &design.Array{ElemType: &design.AttributeDefinition{Type: design.String}}},
+			},
+			Validation: &dslengine.ValidationDefinition{Required: []string{"email"}},
+		},
+	}
+	user.BuiltFrom[payload.TypeName] = payload
+
+	if !user.HasPayloadConversion("UserPayload") {
+		t.Error("expected a conversion to UserPayload")
+	}
+	if fields := user.RoundTripFields(payload); !reflect.DeepEqual(fields, []string{"Age", "Email"}) {
+		t.Errorf("unexpected round trip fields %v", fields)
+	}
+	exp := "n := 1\nv1 := int(0 + n)\nm.Age = &v1"
+	if code := user.RoundTripValues(payload); code != exp {
+		t.Errorf("expected\n%s\ngot\n%s", exp, code)
+	}
+
+	user.RenderTo[payload.TypeName] = &design.MediaTypeDefinition{UserTypeDefinition: payload}
+	if user.HasPayloadConversion("UserPayload") || len(user.RoundTripTypes()) > 0 {
+		t.Error("expected the conversion of the media type rendered to")
+	}
+}
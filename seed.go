@@ -0,0 +1,186 @@
+package gorma
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Gys/goa/dslengine"
+)
+
+// uuidPattern matches the string representation of a UUID.
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{12}$`)
+
+// Context returns the generic definition name used in error messages.
+func (s *SeedDefinition) Context() string {
+	if s.ModelName != "" {
+		return fmt.Sprintf("Seed %#v", s.ModelName)
+	}
+	return "unnamed Seed"
+}
+
+// DSL returns this object's DSL.
+func (s *SeedDefinition) DSL() func() {
+	return s.DefinitionDSL
+}
+
+// Model returns the seeded model, nil if the store doesn't define it.
+func (s *SeedDefinition) Model() *RelationalModelDefinition {
+	if s.Parent == nil {
+		return nil
+	}
+	return s.Parent.RelationalModels[s.ModelName]
+}
+
+// Validate tests whether the Seed definition is consistent: the rows set
+// columns of the model, with values of their type, and identify the
+// records they upsert.
+func (s *SeedDefinition) Validate() error {
+	verr := new(dslengine.ValidationErrors)
+	m := s.Model()
+	if m == nil {
+		verr.Add(s, "model %s is not defined in the store", s.ModelName)
+		return verr
+	}
+	if m.DynamicTableName {
+		verr.Add(s, "model %s has a dynamic table name and can't be seeded", s.ModelName)
+	}
+	columns := make(map[string]*RelationalFieldDefinition)
+	for _, field := range m.columnFields() {
+		columns[field.FieldName] = field
+	}
+	for i, row := range s.Rows {
+		var names []string
+		for name := range row {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			field, ok := columns[name]
+			if !ok {
+				verr.Add(s, "row %d: field %s is not a column of model %s", i+1, name, s.ModelName)
+			} else if !validSeedValue(field, row[name]) {
+				verr.Add(s, "row %d: invalid value %#v for %s field %s", i+1, row[name], keyDatatype(field), name)
+			}
+		}
+		if seedKey(m, row) == nil {
+			verr.Add(s, "row %d: sets neither the primary key nor a unique field of model %s", i+1, s.ModelName)
+		}
+	}
+	if err := verr.AsError(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validSeedValue returns true if the value of a seed row can be stored in
+// the column of the field.
+func validSeedValue(f *RelationalFieldDefinition, value interface{}) bool {
+	t := keyDatatype(f)
+	switch v := value.(type) {
+	case nil:
+		return f.Nullable
+	case bool:
+		return t == Boolean
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return t == Integer || t == BigInteger || t == Decimal || t == BigDecimal
+	case float32, float64:
+		return t == Decimal || t == BigDecimal
+	case string:
+		switch t {
+		case String:
+			return len(v) <= fieldSize(f)
+		case Text:
+			return true
+		case UUID:
+			return uuidPattern.MatchString(v)
+		case JSON:
+			return json.Valid([]byte(v))
+		case Timestamp, NullableTimestamp:
+			_, err := time.Parse(time.RFC3339Nano, v)
+			return err == nil
+		}
+	}
+	return false
+}
+
+// seedKey returns the fields the record a seed row upserts is matched on:
+// the primary key when the row sets it, a unique field otherwise.  It
+// returns nil if the row sets neither.
+func seedKey(m *RelationalModelDefinition, row map[string]interface{}) []*RelationalFieldDefinition {
+	pks := m.PrimaryKeyFields()
+	set := len(pks) > 0
+	for _, pk := range pks {
+		if v, ok := row[pk.FieldName]; !ok || v == nil {
+			set = false
+		}
+	}
+	if set {
+		return pks
+	}
+	for _, field := range m.columnFields() {
+		unique := field.Unique
+		m.IterateIndexes(func(i *IndexDefinition) error {
+			unique = unique || (i.Unique && i.Condition == "" && len(i.Fields) == 1 && i.Fields[0] == field.FieldName)
+			return nil
+		})
+		if v, ok := row[field.FieldName]; ok && v != nil && unique {
+			return []*RelationalFieldDefinition{field}
+		}
+	}
+	return nil
+}
+
+// SeedRows returns the code of the rows upserted by the Seed function of
+// the store: the rows of the models a model belongs to come first.
+func (sd *RelationalStoreDefinition) SeedRows() string {
+	var code []string
+	sd.IterateModelsByDependency(func(m *RelationalModelDefinition) error {
+		for _, seed := range sd.Seeds {
+			if seed.ModelName != m.ModelName {
+				continue
+			}
+			for _, row := range seed.Rows {
+				code = append(code, seedRow(m, row))
+			}
+		}
+		return nil
+	})
+	return strings.Join(code, "\n")
+}
+
+// seedRow returns the code of a seed row: the model, the values of the
+// columns the record is matched on and the values of the other columns.
+func seedRow(m *RelationalModelDefinition, row map[string]interface{}) string {
+	key := seedKey(m, row)
+	isKey := make(map[string]bool)
+	for _, field := range key {
+		isKey[field.FieldName] = true
+	}
+	var keys, values []string
+	for _, field := range m.columnFields() {
+		v, ok := row[field.FieldName]
+		if !ok {
+			continue
+		}
+		value := "nil"
+		if v != nil {
+			value = goLiteral(field, v)
+		}
+		value = fmt.Sprintf("%q: %s", field.ColumnName(), value)
+		if isKey[field.FieldName] {
+			keys = append(keys, value)
+		} else {
+			values = append(values, value)
+		}
+	}
+	if m.SoftDelete() {
+		// restore the records deleted since the previous seeding
+		values = append(values, fmt.Sprintf("%q: nil", m.RelationalFields["DeletedAt"].ColumnName()))
+	}
+	return fmt.Sprintf("{%q, &%s{}, map[string]interface{}{%s}, map[string]interface{}{%s}},",
+		m.ModelName, m.ModelName, strings.Join(keys, ", "), strings.Join(values, ", "))
+}
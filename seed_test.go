@@ -0,0 +1,64 @@
+package gorma_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Gys/gorma"
+)
+
+func TestSeedRows(t *testing.T) {
+	sd := makeStore(gorma.Postgres)
+	user := makeModel(sd, "User")
+	makeField(user, "Email", gorma.String).Unique = true
+	makeField(user, "Admin", gorma.Boolean)
+	makeField(user, "BornAt", gorma.Timestamp).Nullable = true
+	makeField(user, "DeletedAt", gorma.NullableTimestamp).Nullable = true
+	sd.Seeds = []*gorma.SeedDefinition{{
+		Parent:    sd,
+		ModelName: "User",
+		Rows: []map[string]interface{}{
+			{"Email": "root@example.com", "Admin": true, "BornAt": "2020-01-02T03:04:05Z"},
+			{"ID": 2, "Email": "guest@example.com", "BornAt": nil},
+		},
+	}}
+
+	if err := sd.Seeds[0].Validate(); err != nil {
+		t.Fatal(err)
+	}
+	exp := `{"User", &User{}, map[string]interface{}{"email": "root@example.com"}, map[string]interface{}{"admin": true, "born_at": time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), "deleted_at": nil}},
+{"User", &User{}, map[string]interface{}{"id": int(2)}, map[string]interface{}{"born_at": nil, "email": "guest@example.com", "deleted_at": nil}},`
+	if code := sd.SeedRows(); code != exp {
+		t.Errorf("expected\n%s\ngot\n%s", exp, code)
+	}
+}
+
+func TestSeedValidate(t *testing.T) {
+	sd := makeStore(gorma.Postgres)
+	user := makeModel(sd, "User")
+	makeField(user, "Name", gorma.String).Size = 4
+	seed := &gorma.SeedDefinition{
+		Parent:    sd,
+		ModelName: "User",
+		Rows: []map[string]interface{}{
+			{"ID": "one"},
+			{"ID": 2, "Name": "Alexander"},
+			{"Name": "Ann"},
+			{"ID": 4, "Role": "admin"},
+		},
+	}
+	err := seed.Validate()
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, exp := range []string{
+		`row 1: invalid value "one" for integer field ID`,
+		`row 2: invalid value "Alexander" for string field Name`,
+		"row 3: sets neither the primary key nor a unique field of model User",
+		"row 4: field Role is not a column of model User",
+	} {
+		if !strings.Contains(err.Error(), exp) {
+			t.Errorf("expected %q in %q", exp, err.Error())
+		}
+	}
+}
@@ -0,0 +1,648 @@
+package gorma
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// SchemaSnapshot is the database schema of a StorageGroup as generated by a
+// run of Gorma.  It is saved next to the migrations so that the following
+// run can diff the design against it and only generate the statements
+// needed to migrate an existing database.
+type SchemaSnapshot struct {
+	Stores map[string]*StoreSnapshot `json:"stores"`
+}
+
+// StoreSnapshot is the schema of a RelationalStore.  Tables are kept in
+// the order they must be created in.
+type StoreSnapshot struct {
+	Name       string                `json:"name"`
+	Type       RelationalStorageType `json:"type"`
+	Tables     []*TableSnapshot      `json:"tables"`
+	JoinTables []*TableSnapshot      `json:"join_tables,omitempty"`
+}
+
+// TableSnapshot is the schema of the table of a RelationalModel, or of a
+// ManyToMany join table in which case Model is empty.
+type TableSnapshot struct {
+	Model       string                `json:"model,omitempty"`
+	Name        string                `json:"name"`
+	Columns     []*ColumnSnapshot     `json:"columns"`
+	PrimaryKey  []string              `json:"primary_key,omitempty"`
+	ForeignKeys []*ForeignKeySnapshot `json:"foreign_keys,omitempty"`
+	Indexes     []*IndexSnapshot      `json:"indexes,omitempty"`
+}
+
+// ColumnSnapshot is the schema of a column.  Field is the name of the
+// RelationalField the column was generated from and identifies the column
+// across renames.
+type ColumnSnapshot struct {
+	Field      string `json:"field"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Nullable   bool   `json:"nullable"`
+	Default    string `json:"default,omitempty"`
+	Check      string `json:"check,omitempty"`
+	CheckName  string `json:"check_name,omitempty"`
+	Comment    string `json:"comment,omitempty"`
+	Definition string `json:"definition"`
+}
+
+// ForeignKeySnapshot is the schema of a foreign key constraint.  Its name
+// lets the following migrations drop it.
+type ForeignKeySnapshot struct {
+	Name       string `json:"name"`
+	Definition string `json:"definition"`
+}
+
+// IndexSnapshot is the schema of an index.
+type IndexSnapshot struct {
+	Name       string   `json:"name"`
+	Unique     bool     `json:"unique,omitempty"`
+	Columns    []string `json:"columns"`
+	Descending []string `json:"descending,omitempty"`
+	Where      string   `json:"where,omitempty"`
+}
+
+// NewSchemaSnapshot returns the schema of every store of the storage group.
+func NewSchemaSnapshot(sg *StorageGroupDefinition) *SchemaSnapshot {
+	s := &SchemaSnapshot{Stores: make(map[string]*StoreSnapshot)}
+	sg.IterateStores(func(store *RelationalStoreDefinition) error {
+		s.Stores[store.Name] = store.Snapshot()
+		return nil
+	})
+	return s
+}
+
+// LoadSchemaSnapshot reads a snapshot saved by a previous run.  It returns
+// nil and no error if the file doesn't exist.
+func LoadSchemaSnapshot(filename string) (*SchemaSnapshot, error) {
+	b, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s SchemaSnapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema snapshot %s: %s", filename, err)
+	}
+	return &s, nil
+}
+
+// Save writes the snapshot to filename.
+func (s *SchemaSnapshot) Save(filename string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, append(b, '\n'), 0644)
+}
+
+// Snapshot returns the schema of the store.
+func (sd *RelationalStoreDefinition) Snapshot() *StoreSnapshot {
+	return sd.snapshot(sd.Type)
+}
+
+// snapshot returns the schema of the store in the dialect.
+func (sd *RelationalStoreDefinition) snapshot(dialect RelationalStorageType) *StoreSnapshot {
+	s := &StoreSnapshot{Name: sd.Name, Type: dialect}
+	sd.IterateModelsByDependency(func(m *RelationalModelDefinition) error {
+		s.Tables = append(s.Tables, m.snapshot(dialect))
+		return nil
+	})
+	for _, m2m := range sd.joinTables() {
+		s.JoinTables = append(s.JoinTables, m2m.snapshot(dialect))
+	}
+	return s
+}
+
+// Snapshot returns the schema of the model's table.
+func (f *RelationalModelDefinition) Snapshot() *TableSnapshot {
+	return f.snapshot(f.Dialect())
+}
+
+// snapshot returns the schema of the model's table in the dialect.
+func (f *RelationalModelDefinition) snapshot(dialect RelationalStorageType) *TableSnapshot {
+	t := &TableSnapshot{Model: f.ModelName, Name: f.DatabaseTableName()}
+
+	inlinePK := false
+	f.IterateFields(func(field *RelationalFieldDefinition) error {
+		if !field.IsColumn() {
+			return nil
+		}
+		if dialect == SQLite3 && isAutoIncrement(field) {
+			// SQLite only allows AUTOINCREMENT on an inline primary key.
+			inlinePK = true
+		}
+		for _, key := range keyFields(field) {
+			t.Columns = append(t.Columns, columnSnapshot(key, dialect, t.Name))
+		}
+		return nil
+	})
+	if !inlinePK {
+		for _, pk := range f.PrimaryKeyFields() {
+			t.PrimaryKey = append(t.PrimaryKey, pk.ColumnName())
+		}
+	}
+	t.ForeignKeys = f.foreignKeys(dialect)
+	t.Indexes = f.indexes()
+	return t
+}
+
+// columnSnapshot returns the schema of the field's column in the table.
+func columnSnapshot(field *RelationalFieldDefinition, dialect RelationalStorageType, table string) *ColumnSnapshot {
+	c := &ColumnSnapshot{
+		Field:      field.FieldName,
+		Name:       field.ColumnName(),
+		Type:       sqlDatatype(field, dialect),
+		Nullable:   field.Nullable,
+		Definition: columnDefinition(field, dialect),
+	}
+	if field.Default != nil {
+		c.Default = sqlLiteral(field.Default, dialect)
+	}
+	if check := columnCheck(field, dialect); check != "" {
+		c.Check = check
+		c.CheckName = checkName(table, c.Name)
+	}
+	if dialect == Postgres || dialect == None {
+		// MySQL comments are part of the column definition and
+		// SQLite has none.
+		c.Comment = field.Description
+	}
+	return c
+}
+
+// Snapshot returns the schema of the relationship's join table.
+func (m *ManyToManyDefinition) Snapshot() *TableSnapshot {
+	return m.snapshot(m.Left.Dialect())
+}
+
+// snapshot returns the schema of the relationship's join table in the
+// dialect.
+func (m *ManyToManyDefinition) snapshot(dialect RelationalStorageType) *TableSnapshot {
+	left, right := m.resolve(m.Left), m.resolve(m.Right)
+	lcol, lref, ltype := joinColumn(left, dialect)
+	rcol, rref, rtype := joinColumn(right, dialect)
+	return &TableSnapshot{
+		Name: m.DatabaseField,
+		Columns: []*ColumnSnapshot{
+			{Field: lcol, Name: lcol, Type: ltype, Definition: fmt.Sprintf("%s %s NOT NULL", quoteIdentifier(dialect, lcol), ltype)},
+			{Field: rcol, Name: rcol, Type: rtype, Definition: fmt.Sprintf("%s %s NOT NULL", quoteIdentifier(dialect, rcol), rtype)},
+		},
+		PrimaryKey: []string{lcol, rcol},
+		ForeignKeys: []*ForeignKeySnapshot{
+			foreignKey(dialect, m.DatabaseField, []string{lcol}, left.DatabaseTableName(), []string{lref}),
+			foreignKey(dialect, m.DatabaseField, []string{rcol}, right.DatabaseTableName(), []string{rref}),
+		},
+	}
+}
+
+// createSQL returns the CREATE TABLE statement for the table.
+func (t *TableSnapshot) createSQL(dialect RelationalStorageType) string {
+	var lines []string
+	for _, c := range t.Columns {
+		lines = append(lines, "\t"+c.Definition)
+	}
+	if len(t.PrimaryKey) > 0 {
+		var pks []string
+		for _, pk := range t.PrimaryKey {
+			pks = append(pks, quoteIdentifier(dialect, pk))
+		}
+		lines = append(lines, fmt.Sprintf("\tPRIMARY KEY (%s)", strings.Join(pks, ", ")))
+	}
+	for _, fk := range t.ForeignKeys {
+		lines = append(lines, "\t"+fk.sql(dialect))
+	}
+	for _, c := range t.Columns {
+		if c.Check != "" {
+			lines = append(lines, "\t"+c.checkSQL(dialect))
+		}
+	}
+	sql := fmt.Sprintf("CREATE TABLE %s (\n%s\n);\n", quoteIdentifier(dialect, t.Name), strings.Join(lines, ",\n"))
+	for _, c := range t.Columns {
+		if c.Comment != "" {
+			sql += c.commentSQL(dialect, t.Name)
+		}
+	}
+	return sql
+}
+
+// checkSQL returns the CHECK constraint of the column.
+func (c *ColumnSnapshot) checkSQL(dialect RelationalStorageType) string {
+	return fmt.Sprintf("CONSTRAINT %s CHECK (%s)", quoteIdentifier(dialect, c.CheckName), c.Check)
+}
+
+// commentSQL returns the COMMENT ON COLUMN statement of the column.
+func (c *ColumnSnapshot) commentSQL(dialect RelationalStorageType, table string) string {
+	comment := "NULL"
+	if c.Comment != "" {
+		comment = sqlLiteral(c.Comment, dialect)
+	}
+	return fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s;\n", quoteIdentifier(dialect, table),
+		quoteIdentifier(dialect, c.Name), comment)
+}
+
+// sql returns the named FOREIGN KEY constraint.
+func (fk *ForeignKeySnapshot) sql(dialect RelationalStorageType) string {
+	return fmt.Sprintf("CONSTRAINT %s %s", quoteIdentifier(dialect, fk.Name), fk.Definition)
+}
+
+// dropSQL returns the statement dropping the foreign key constraint from
+// the table.
+func (fk *ForeignKeySnapshot) dropSQL(dialect RelationalStorageType, table string) string {
+	drop := "CONSTRAINT"
+	if dialect == MySQL {
+		drop = "FOREIGN KEY"
+	}
+	return fmt.Sprintf("ALTER TABLE %s DROP %s %s;\n", quoteIdentifier(dialect, table), drop, quoteIdentifier(dialect, fk.Name))
+}
+
+// dropSQL returns the DROP TABLE statement for the table.
+func (t *TableSnapshot) dropSQL(dialect RelationalStorageType) string {
+	return fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", quoteIdentifier(dialect, t.Name))
+}
+
+// createSQL returns the CREATE INDEX statement for the index.
+func (i *IndexSnapshot) createSQL(dialect RelationalStorageType, table string) string {
+	create := "CREATE INDEX"
+	if i.Unique {
+		create = "CREATE UNIQUE INDEX"
+	}
+	desc := make(map[string]bool)
+	for _, c := range i.Descending {
+		desc[c] = true
+	}
+	var cols []string
+	for _, c := range i.Columns {
+		col := quoteIdentifier(dialect, c)
+		if desc[c] {
+			col += " DESC"
+		}
+		cols = append(cols, col)
+	}
+	var where string
+	if i.Where != "" && dialect != MySQL {
+		where = " WHERE " + i.Where
+	}
+	return fmt.Sprintf("%s %s ON %s (%s)%s;\n", create, quoteIdentifier(dialect, i.Name),
+		quoteIdentifier(dialect, table), strings.Join(cols, ", "), where)
+}
+
+// dropSQL returns the DROP INDEX statement for the index.
+func (i *IndexSnapshot) dropSQL(dialect RelationalStorageType, table string) string {
+	if dialect == MySQL {
+		return fmt.Sprintf("DROP INDEX %s ON %s;\n", quoteIdentifier(dialect, i.Name), quoteIdentifier(dialect, table))
+	}
+	return fmt.Sprintf("DROP INDEX %s;\n", quoteIdentifier(dialect, i.Name))
+}
+
+func (i *IndexSnapshot) equal(o *IndexSnapshot) bool {
+	return i.Unique == o.Unique && i.Where == o.Where &&
+		strings.Join(i.Columns, ",") == strings.Join(o.Columns, ",") &&
+		strings.Join(i.Descending, ",") == strings.Join(o.Descending, ",")
+}
+
+func (c *ColumnSnapshot) equal(o *ColumnSnapshot) bool {
+	return c.Type == o.Type && c.Nullable == o.Nullable && c.Definition == o.Definition &&
+		c.Check == o.Check && c.CheckName == o.CheckName && c.Comment == o.Comment
+}
+
+// Diff returns the statements migrating a database from the schema of s to
+// the schema of to.  Tables are matched by model name and columns by field
+// name, so that changing an Alias or a DatabaseFieldName renames the table
+// or column instead of recreating it.  A nil s diffs from an empty schema.
+func (s *StoreSnapshot) Diff(to *StoreSnapshot) []string {
+	if s == nil {
+		s = &StoreSnapshot{}
+	}
+	dialect := to.Type
+	var stmts []string
+
+	// drop the join tables first, they reference the other tables
+	joins := make(map[string]*TableSnapshot)
+	for _, t := range to.JoinTables {
+		joins[t.Name] = t
+	}
+	for _, t := range s.JoinTables {
+		if _, ok := joins[t.Name]; !ok {
+			stmts = append(stmts, t.dropSQL(dialect))
+		}
+	}
+
+	from := make(map[string]*TableSnapshot)
+	for _, t := range s.Tables {
+		from[t.Model] = t
+	}
+	tables := make(map[string]*TableSnapshot)
+	for _, t := range to.Tables {
+		tables[t.Model] = t
+		old, ok := from[t.Model]
+		if !ok {
+			stmts = append(stmts, t.createSQL(dialect))
+			for _, i := range t.Indexes {
+				stmts = append(stmts, i.createSQL(dialect, t.Name))
+			}
+			continue
+		}
+		stmts = append(stmts, old.alterSQL(dialect, t)...)
+	}
+	for i := len(s.Tables) - 1; i >= 0; i-- {
+		if _, ok := tables[s.Tables[i].Model]; !ok {
+			stmts = append(stmts, s.Tables[i].dropSQL(dialect))
+		}
+	}
+
+	old := make(map[string]*TableSnapshot)
+	for _, t := range s.JoinTables {
+		old[t.Name] = t
+	}
+	for _, t := range to.JoinTables {
+		if _, ok := old[t.Name]; !ok {
+			stmts = append(stmts, t.createSQL(dialect))
+		}
+	}
+	return stmts
+}
+
+// alterSQL returns the statements migrating the table t to the table to.
+func (t *TableSnapshot) alterSQL(dialect RelationalStorageType, to *TableSnapshot) []string {
+	var stmts []string
+	if t.Name != to.Name {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s RENAME TO %s;\n",
+			quoteIdentifier(dialect, t.Name), quoteIdentifier(dialect, to.Name)))
+	}
+	table := quoteIdentifier(dialect, to.Name)
+
+	from := make(map[string]*ColumnSnapshot)
+	for _, c := range t.Columns {
+		from[c.Field] = c
+	}
+	columns := make(map[string]*ColumnSnapshot)
+	for _, c := range to.Columns {
+		columns[c.Field] = c
+	}
+
+	var added, changed, dropped, renamed []*ColumnSnapshot
+	for _, c := range to.Columns {
+		old, ok := from[c.Field]
+		switch {
+		case !ok:
+			added = append(added, c)
+		case old.Name != c.Name:
+			renamed = append(renamed, c)
+			if !old.equal(c) {
+				changed = append(changed, c)
+			}
+		case !old.equal(c):
+			changed = append(changed, c)
+		}
+	}
+	for _, c := range t.Columns {
+		if _, ok := columns[c.Field]; !ok {
+			dropped = append(dropped, c)
+		}
+	}
+
+	addedFKs := foreignKeysMissing(t.ForeignKeys, to.ForeignKeys)
+	droppedFKs := foreignKeysMissing(to.ForeignKeys, t.ForeignKeys)
+
+	if dialect == SQLite3 && (len(changed) > 0 || len(dropped) > 0 || hasCheck(added) ||
+		len(addedFKs) > 0 || len(droppedFKs) > 0) {
+		// SQLite can't alter columns or add or drop constraints, the
+		// table has to be rebuilt.
+		return append(stmts, t.rebuildSQL(dialect, to)...)
+	}
+
+	indexes := make(map[string]*IndexSnapshot)
+	for _, i := range to.Indexes {
+		indexes[i.Name] = i
+	}
+	for _, i := range t.Indexes {
+		if n, ok := indexes[i.Name]; !ok || !n.equal(i) {
+			stmts = append(stmts, i.dropSQL(dialect, to.Name))
+		}
+	}
+
+	// drop the foreign keys removed or changed before their columns
+	for _, fk := range droppedFKs {
+		stmts = append(stmts, fk.dropSQL(dialect, to.Name))
+	}
+
+	for _, c := range renamed {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;\n", table,
+			quoteIdentifier(dialect, from[c.Field].Name), quoteIdentifier(dialect, c.Name)))
+	}
+	for _, c := range dropped {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;\n", table, quoteIdentifier(dialect, c.Name)))
+	}
+	for _, c := range added {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;\n", table, c.Definition))
+		if c.Check != "" {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ADD %s;\n", table, c.checkSQL(dialect)))
+		}
+		if c.Comment != "" {
+			stmts = append(stmts, c.commentSQL(dialect, to.Name))
+		}
+	}
+	for _, c := range changed {
+		stmts = append(stmts, alterColumnSQL(dialect, to.Name, from[c.Field], c)...)
+	}
+
+	for _, fk := range addedFKs {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ADD %s;\n", table, fk.sql(dialect)))
+	}
+
+	old := make(map[string]*IndexSnapshot)
+	for _, i := range t.Indexes {
+		old[i.Name] = i
+	}
+	for _, i := range to.Indexes {
+		if o, ok := old[i.Name]; !ok || !o.equal(i) {
+			stmts = append(stmts, i.createSQL(dialect, to.Name))
+		}
+	}
+	return stmts
+}
+
+// alterColumnSQL returns the statements changing the type, nullability,
+// default value, check constraint or comment of a column of the table.
+func alterColumnSQL(dialect RelationalStorageType, tableName string, from, to *ColumnSnapshot) []string {
+	table := quoteIdentifier(dialect, tableName)
+	col := quoteIdentifier(dialect, to.Name)
+	checkChanged := from.Check != to.Check || from.CheckName != to.CheckName
+	var stmts []string
+	if from.Check != "" && checkChanged {
+		drop := "CONSTRAINT"
+		if dialect == MySQL {
+			drop = "CHECK"
+		}
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s DROP %s %s;\n", table, drop, quoteIdentifier(dialect, from.CheckName)))
+	}
+	if dialect == MySQL {
+		if from.Definition != to.Definition {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s;\n", table, to.Definition))
+		}
+	} else {
+		stmts = append(stmts, alterColumnAttributesSQL(dialect, table, col, from, to)...)
+		if from.Comment != to.Comment {
+			stmts = append(stmts, to.commentSQL(dialect, tableName))
+		}
+	}
+	if to.Check != "" && checkChanged {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ADD %s;\n", table, to.checkSQL(dialect)))
+	}
+	return stmts
+}
+
+// alterColumnAttributesSQL returns the ALTER COLUMN statements changing
+// the type, nullability or default value of a column.
+func alterColumnAttributesSQL(dialect RelationalStorageType, table, col string, from, to *ColumnSnapshot) []string {
+	var stmts []string
+	if from.Type != to.Type {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;\n", table, col, to.Type))
+	}
+	if from.Nullable != to.Nullable {
+		if to.Nullable {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;\n", table, col))
+		} else {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;\n", table, col))
+		}
+	}
+	if from.Default != to.Default {
+		if to.Default == "" {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;\n", table, col))
+		} else {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;\n", table, col, to.Default))
+		}
+	}
+	return stmts
+}
+
+// foreignKeysMissing returns the foreign keys of to missing from from or
+// defined differently there.
+func foreignKeysMissing(from, to []*ForeignKeySnapshot) []*ForeignKeySnapshot {
+	fks := make(map[string]*ForeignKeySnapshot)
+	for _, fk := range from {
+		fks[fk.Name] = fk
+	}
+	var missing []*ForeignKeySnapshot
+	for _, fk := range to {
+		if o, ok := fks[fk.Name]; !ok || o.Definition != fk.Definition {
+			missing = append(missing, fk)
+		}
+	}
+	return missing
+}
+
+// hasCheck returns true if one of the columns has a check constraint.
+func hasCheck(columns []*ColumnSnapshot) bool {
+	for _, c := range columns {
+		if c.Check != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// rebuildSQL returns the statements recreating the table t as to, copying
+// the columns the two have in common.
+func (t *TableSnapshot) rebuildSQL(dialect RelationalStorageType, to *TableSnapshot) []string {
+	tmp := *to
+	tmp.Name = to.Name + "__gorma_new"
+
+	from := make(map[string]*ColumnSnapshot)
+	for _, c := range t.Columns {
+		from[c.Field] = c
+	}
+	var dst, src []string
+	for _, c := range to.Columns {
+		if old, ok := from[c.Field]; ok {
+			dst = append(dst, quoteIdentifier(dialect, c.Name))
+			src = append(src, quoteIdentifier(dialect, old.Name))
+		}
+	}
+
+	stmts := []string{
+		tmp.createSQL(dialect),
+		fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s;\n", quoteIdentifier(dialect, tmp.Name),
+			strings.Join(dst, ", "), strings.Join(src, ", "), quoteIdentifier(dialect, to.Name)),
+		fmt.Sprintf("DROP TABLE %s;\n", quoteIdentifier(dialect, to.Name)),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s;\n", quoteIdentifier(dialect, tmp.Name), quoteIdentifier(dialect, to.Name)),
+	}
+	for _, i := range to.Indexes {
+		stmts = append(stmts, i.createSQL(dialect, to.Name))
+	}
+	return stmts
+}
+
+// indexes returns the indexes of the model: the ones declared with the
+// Index and UniqueIndex DSL, one per field declared Unique and the ones of
+// fields tagged with gorm's `index` or `unique_index` settings.  Fields
+// sharing an index name in their tags are combined into a composite index.
+func (f *RelationalModelDefinition) indexes() []*IndexSnapshot {
+	table := f.DatabaseTableName()
+	indexes := make(map[string]*IndexSnapshot)
+	f.IterateIndexes(func(index *IndexDefinition) error {
+		i := &IndexSnapshot{Name: index.Name, Unique: index.Unique, Where: index.Condition}
+		for _, name := range index.Fields {
+			field, ok := f.RelationalFields[name]
+			if !ok {
+				continue
+			}
+			i.Columns = append(i.Columns, field.ColumnName())
+			if index.Descending[name] {
+				i.Descending = append(i.Descending, field.ColumnName())
+			}
+		}
+		indexes[i.Name] = i
+		return nil
+	})
+	f.IterateFields(func(field *RelationalFieldDefinition) error {
+		if !field.IsColumn() {
+			return nil
+		}
+		if field.Unique {
+			name := uniqueIndexName(field)
+			indexes[name] = &IndexSnapshot{Name: name, Unique: true, Columns: []string{field.ColumnName()}}
+		}
+		settings := sqlTagSettings(field.SQLTag)
+		for _, key := range []string{"INDEX", "UNIQUE_INDEX"} {
+			name, ok := settings[key]
+			if !ok {
+				continue
+			}
+			if name == key {
+				name = fmt.Sprintf("idx_%s_%s", table, field.ColumnName())
+				if key == "UNIQUE_INDEX" {
+					name = "u" + name
+				}
+			}
+			i, ok := indexes[name]
+			if !ok {
+				i = &IndexSnapshot{Name: name}
+				indexes[name] = i
+			}
+			i.Columns = append(i.Columns, field.ColumnName())
+			i.Unique = i.Unique || key == "UNIQUE_INDEX"
+		}
+		return nil
+	})
+
+	var names []string
+	for name := range indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var result []*IndexSnapshot
+	for _, name := range names {
+		result = append(result, indexes[name])
+	}
+	return result
+}
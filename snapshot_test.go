@@ -0,0 +1,184 @@
+package gorma_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Gys/gorma"
+)
+
+func TestDiffNoChanges(t *testing.T) {
+	sd := makeStore(gorma.Postgres)
+	makeField(makeModel(sd, "User"), "Name", gorma.String)
+
+	stmts := sd.Snapshot().Diff(sd.Snapshot())
+	if len(stmts) != 0 {
+		t.Errorf("Expected no statements, got %v", stmts)
+	}
+}
+
+func TestDiffColumns(t *testing.T) {
+	sd := makeStore(gorma.Postgres)
+	user := makeModel(sd, "User")
+	makeField(user, "Name", gorma.String)
+	makeField(user, "Nickname", gorma.String)
+	old := sd.Snapshot()
+
+	delete(user.RelationalFields, "Nickname")
+	makeField(user, "Age", gorma.Integer)
+	user.RelationalFields["Name"].Nullable = true
+	user.RelationalFields["Name"].SQLTag = "index"
+	user.Alias = "people"
+
+	exp := []string{
+		"ALTER TABLE \"users\" RENAME TO \"people\";\n",
+		"ALTER TABLE \"people\" DROP COLUMN \"nickname\";\n",
+		"ALTER TABLE \"people\" ADD COLUMN \"age\" INTEGER NOT NULL;\n",
+		"ALTER TABLE \"people\" ALTER COLUMN \"name\" DROP NOT NULL;\n",
+		"CREATE INDEX \"idx_people_name\" ON \"people\" (\"name\");\n",
+	}
+	stmts := old.Diff(sd.Snapshot())
+	if !reflect.DeepEqual(stmts, exp) {
+		t.Errorf("Expected %q, got %q", exp, stmts)
+	}
+}
+
+func TestDiffConstraints(t *testing.T) {
+	sd := makeStore(gorma.Postgres)
+	f := makeField(makeModel(sd, "Order"), "Status", gorma.String)
+	f.Check = "status <> ''"
+	old := sd.Snapshot()
+
+	f.Default = "pending"
+	f.Check = "status IN ('pending', 'paid')"
+	f.Description = "fulfilment status"
+
+	exp := []string{
+		"ALTER TABLE \"orders\" DROP CONSTRAINT \"chk_orders_status\";\n",
+		"ALTER TABLE \"orders\" ALTER COLUMN \"status\" SET DEFAULT 'pending';\n",
+		"COMMENT ON COLUMN \"orders\".\"status\" IS 'fulfilment status';\n",
+		"ALTER TABLE \"orders\" ADD CONSTRAINT \"chk_orders_status\" CHECK (status IN ('pending', 'paid'));\n",
+	}
+	stmts := old.Diff(sd.Snapshot())
+	if !reflect.DeepEqual(stmts, exp) {
+		t.Errorf("Expected %q, got %q", exp, stmts)
+	}
+}
+
+func TestDiffForeignKeys(t *testing.T) {
+	sd := makeStore(gorma.MySQL)
+	user := makeModel(sd, "User")
+	order := makeModel(sd, "Order")
+	makeField(order, "UserID", gorma.BelongsTo)
+	order.BelongsTo["User"] = user
+	old := sd.Snapshot()
+	delete(order.RelationalFields, "UserID")
+	delete(order.BelongsTo, "User")
+
+	exp := []string{
+		"ALTER TABLE `orders` DROP FOREIGN KEY `fk_orders_user_id`;\n",
+		"ALTER TABLE `orders` DROP COLUMN `user_id`;\n",
+	}
+	stmts := old.Diff(sd.Snapshot())
+	if !reflect.DeepEqual(stmts, exp) {
+		t.Errorf("Expected %q, got %q", exp, stmts)
+	}
+	exp = []string{
+		"ALTER TABLE `orders` ADD COLUMN `user_id` INT NOT NULL;\n",
+		"ALTER TABLE `orders` ADD CONSTRAINT `fk_orders_user_id` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`);\n",
+	}
+	stmts = sd.Snapshot().Diff(old)
+	if !reflect.DeepEqual(stmts, exp) {
+		t.Errorf("Expected %q, got %q", exp, stmts)
+	}
+}
+
+func TestDiffTables(t *testing.T) {
+	sd := makeStore(gorma.MySQL)
+	makeModel(sd, "User")
+	old := sd.Snapshot()
+	makeModel(sd, "Account")
+
+	up := old.Diff(sd.Snapshot())
+	if len(up) != 1 || !strings.HasPrefix(up[0], "CREATE TABLE `accounts`") {
+		t.Errorf("Expected accounts to be created, got %q", up)
+	}
+	down := sd.Snapshot().Diff(old)
+	if len(down) != 1 || down[0] != "DROP TABLE IF EXISTS `accounts`;\n" {
+		t.Errorf("Expected accounts to be dropped, got %q", down)
+	}
+}
+
+func TestDiffSQLiteRebuild(t *testing.T) {
+	sd := makeStore(gorma.SQLite3)
+	user := makeModel(sd, "User")
+	makeField(user, "Name", gorma.String)
+	old := sd.Snapshot()
+	user.RelationalFields["Name"].Datatype = gorma.Text
+
+	stmts := old.Diff(sd.Snapshot())
+	if len(stmts) != 4 {
+		t.Fatalf("Expected the table to be rebuilt, got %q", stmts)
+	}
+	exp := "INSERT INTO \"users__gorma_new\" (\"id\", \"name\") SELECT \"id\", \"name\" FROM \"users\";\n"
+	if stmts[1] != exp {
+		t.Errorf("Expected %q, got %q", exp, stmts[1])
+	}
+}
+
+func TestDiffSQLiteForeignKeys(t *testing.T) {
+	sd := makeStore(gorma.SQLite3)
+	user := makeModel(sd, "User")
+	order := makeModel(sd, "Order")
+	old := sd.Snapshot()
+	makeField(order, "UserID", gorma.BelongsTo)
+	order.BelongsTo["User"] = user
+
+	stmts := old.Diff(sd.Snapshot())
+	if len(stmts) != 4 {
+		t.Fatalf("Expected the table to be rebuilt, got %q", stmts)
+	}
+	fk := "CONSTRAINT \"fk_orders_user_id\" FOREIGN KEY (\"user_id\") REFERENCES \"users\" (\"id\")"
+	if !strings.Contains(stmts[0], fk) {
+		t.Errorf("Expected %q to contain %q", stmts[0], fk)
+	}
+
+	stmts = sd.Snapshot().Diff(old)
+	if len(stmts) != 4 || strings.Contains(stmts[0], "FOREIGN KEY") {
+		t.Errorf("Expected the table to be rebuilt without the foreign key, got %q", stmts)
+	}
+}
+
+func TestSchemaSnapshotSave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gorma")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "schema.json")
+
+	s, err := gorma.LoadSchemaSnapshot(filename)
+	if err != nil || s != nil {
+		t.Fatalf("Expected no snapshot, got %v, %v", s, err)
+	}
+
+	sg := gorma.NewStorageGroupDefinition()
+	sd := makeStore(gorma.Postgres)
+	sg.RelationalStores[sd.Name] = sd
+	makeField(makeModel(sd, "User"), "Name", gorma.String)
+	if err := gorma.NewSchemaSnapshot(sg).Save(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = gorma.LoadSchemaSnapshot(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stmts := s.Stores[sd.Name].Diff(sd.Snapshot()); len(stmts) != 0 {
+		t.Errorf("Expected the loaded snapshot to match the design, got %q", stmts)
+	}
+}
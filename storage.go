@@ -0,0 +1,195 @@
+package gorma
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Gys/goa/goagen/codegen"
+)
+
+// StorageMethod describes a method of the storage interface of a model,
+// the interface and its mocks are generated from it.
+type StorageMethod struct {
+	Name    string
+	Params  []*StorageParam // ctx first
+	Results []string
+}
+
+// StorageParam is a parameter of a storage method.
+type StorageParam struct {
+	Name string
+	Type string
+}
+
+// Signature returns the signature of the method in the interface.
+func (m *StorageMethod) Signature() string {
+	params := make([]string, len(m.Params))
+	for i, p := range m.Params {
+		params[i] = p.Name + " " + p.Type
+	}
+	return fmt.Sprintf("%s(%s) %s", m.Name, strings.Join(params, ", "), m.ResultTypes())
+}
+
+// ParamTypes returns the types of the parameters of the method.
+func (m *StorageMethod) ParamTypes() string {
+	types := make([]string, len(m.Params))
+	for i, p := range m.Params {
+		types[i] = p.Type
+	}
+	return strings.Join(types, ", ")
+}
+
+// ParamNames returns the names of the parameters of the method.
+func (m *StorageMethod) ParamNames() string {
+	names := make([]string, len(m.Params))
+	for i, p := range m.Params {
+		names[i] = p.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// ResultTypes returns the result types of the method.
+func (m *StorageMethod) ResultTypes() string {
+	if len(m.Results) == 1 {
+		return m.Results[0]
+	}
+	return "(" + strings.Join(m.Results, ", ") + ")"
+}
+
+// ZeroResults returns the zero values of the results of the method, all of
+// them pointers, slices, interfaces or errors.
+func (m *StorageMethod) ZeroResults() string {
+	zeros := make([]string, len(m.Results))
+	for i := range zeros {
+		zeros[i] = "nil"
+	}
+	return strings.Join(zeros, ", ")
+}
+
+// StorageMethods returns the methods of the model's storage interface, in
+// the order of the interface.  legacyLists selects the List<MediaType>
+// methods without list options nor error.
+func (f *RelationalModelDefinition) StorageMethods(legacyLists bool) []*StorageMethod {
+	ctx := []*StorageParam{{Name: "ctx", Type: "context.Context"}}
+	if f.DynamicTableName {
+		ctx = append(ctx, &StorageParam{Name: "tableName", Type: "string"})
+	}
+	params := func(more ...[]*StorageParam) []*StorageParam {
+		ps := append([]*StorageParam{}, ctx...)
+		for _, m := range more {
+			ps = append(ps, m...)
+		}
+		return ps
+	}
+	pks := f.pkParams()
+	parents := f.parentParams()
+	model := []*StorageParam{{Name: f.LowerName(), Type: "*" + f.ModelName}}
+	opts := []*StorageParam{{Name: "opts", Type: "*" + f.ModelName + "ListOptions"}}
+
+	methods := []*StorageMethod{
+		{Name: "DB", Results: []string{"interface{}"}},
+		{Name: "List", Params: params(opts), Results: []string{"[]*" + f.ModelName, "*ListPage", "error"}},
+		{Name: "Get", Params: params(pks), Results: []string{"*" + f.ModelName, "error"}},
+		{Name: "Add", Params: params(model), Results: []string{"error"}},
+		{Name: "Update", Params: params(model), Results: []string{"error"}},
+		{Name: "Delete", Params: params(pks), Results: []string{"error"}},
+	}
+	var rnames []string
+	for name := range f.RenderTo {
+		rnames = append(rnames, name)
+	}
+	sort.Strings(rnames)
+	for _, rname := range rnames {
+		mt := f.RenderTo[rname]
+		var vnames []string
+		for vname := range f.RenderedViews(rname) {
+			vnames = append(vnames, vname)
+		}
+		sort.Strings(vnames)
+		for _, vname := range vnames {
+			name := codegen.Goify(mt.TypeName, true)
+			if vname != "default" {
+				name += codegen.Goify(vname, true)
+			}
+			list := &StorageMethod{Name: "List" + name, Params: params(parents, opts), Results: []string{"[]*app." + name, "*ListPage", "error"}}
+			if legacyLists {
+				list = &StorageMethod{Name: "List" + name, Params: params(parents), Results: []string{"[]*app." + name}}
+			}
+			methods = append(methods, list,
+				&StorageMethod{Name: "One" + name, Params: params(f.oneParams()), Results: []string{"*app." + name, "error"}})
+		}
+	}
+	var bnames []string
+	for name := range f.BuiltFrom {
+		bnames = append(bnames, name)
+	}
+	sort.Strings(bnames)
+	for _, bname := range bnames {
+		payload := []*StorageParam{{Name: "payload", Type: "*app." + codegen.Goify(bname, true)}}
+		methods = append(methods, &StorageMethod{Name: "UpdateFrom" + bname, Params: params(payload, pks), Results: []string{"error"}})
+	}
+	return methods
+}
+
+// pkParams returns the parameters identifying a record, see PKAttributes.
+func (f *RelationalModelDefinition) pkParams() []*StorageParam {
+	var pks []*StorageParam
+	for _, pk := range f.PrimaryKeyFields() {
+		pks = append(pks, &StorageParam{Name: codegen.Goify(pk.DatabaseFieldName, false), Type: strings.TrimSpace(goDatatype(pk, true))})
+	}
+	return pks
+}
+
+// parentParams returns the parameters identifying the parents of a record,
+// see BelongsToAttributes.
+func (f *RelationalModelDefinition) parentParams() []*StorageParam {
+	var parents []*StorageParam
+	for _, name := range sortedKeys(f.BelongsTo) {
+		for _, key := range f.foreignKeyFields(f.BelongsTo[name].ModelName) {
+			parents = append(parents, &StorageParam{Name: codegen.Goify(key.FieldName, false), Type: keyGoDatatype(key)})
+		}
+	}
+	return parents
+}
+
+// oneParams returns the parameters of the One<MediaType> methods: the
+// primary keys, then the keys of the parents which aren't primary keys
+// already, like the UserID of a Membership keyed by UserID and GroupID
+// that belongs to a User.  The scopes filtering on the parents get the
+// value of the primary key then.
+func (f *RelationalModelDefinition) oneParams() []*StorageParam {
+	params := f.pkParams()
+	seen := make(map[string]bool)
+	for _, p := range params {
+		seen[p.Name] = true
+	}
+	for _, p := range f.parentParams() {
+		if !seen[p.Name] {
+			seen[p.Name] = true
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// OneAttributes returns the parameters of the One<MediaType> methods, see
+// oneParams.
+func (f *RelationalModelDefinition) OneAttributes() string {
+	var attr []string
+	for _, p := range f.oneParams() {
+		attr = append(attr, p.Name+" "+p.Type)
+	}
+	return strings.Join(attr, ", ")
+}
+
+// sortedKeys returns the names of the models of a relationship map in the
+// order templates range over them.
+func sortedKeys(models map[string]*RelationalModelDefinition) []string {
+	var keys []string
+	for key := range models {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
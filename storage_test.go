@@ -0,0 +1,106 @@
+package gorma_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Gys/goa/design"
+	"github.com/Gys/gorma"
+)
+
+func TestStorageMethods(t *testing.T) {
+	sd := makeStore(gorma.Postgres)
+	user := makeModel(sd, "User")
+	m := makeModel(sd, "Membership")
+	m.BelongsTo["User"] = user
+	makeField(m, "UserID", gorma.Integer)
+	m.DynamicTableName = true
+
+	methods := m.StorageMethods(false)
+	exp := []string{
+		"DB() interface{}",
+		"List(ctx context.Context, tableName string, opts *MembershipListOptions) ([]*Membership, *ListPage, error)",
+		"Get(ctx context.Context, tableName string, id int) (*Membership, error)",
+		"Add(ctx context.Context, tableName string, membership *Membership) error",
+		"Update(ctx context.Context, tableName string, membership *Membership) error",
+		"Delete(ctx context.Context, tableName string, id int) error",
+	}
+	if len(methods) != len(exp) {
+		t.Fatalf("expected %d methods, got %d", len(exp), len(methods))
+	}
+	for i, e := range exp {
+		if sig := methods[i].Signature(); sig != e {
+			t.Errorf("expected %q, got %q", e, sig)
+		}
+	}
+	get := methods[2]
+	if types := get.ParamTypes(); types != "context.Context, string, int" {
+		t.Errorf("unexpected parameter types %q", types)
+	}
+	if names := get.ParamNames(); names != "ctx, tableName, id" {
+		t.Errorf("unexpected parameter names %q", names)
+	}
+	if zeros := methods[1].ZeroResults(); zeros != "nil, nil, nil" {
+		t.Errorf("unexpected zero results %q", zeros)
+	}
+}
+
+func TestStorageMethodsCompositeKeyParent(t *testing.T) {
+	sd := makeStore(gorma.Postgres)
+	user := makeModel(sd, "User")
+	m := makeModel(sd, "Membership")
+	delete(m.RelationalFields, "ID")
+	userID := makeField(m, "UserID", gorma.Integer)
+	groupID := makeField(m, "GroupID", gorma.Integer)
+	m.PrimaryKeys = []*gorma.RelationalFieldDefinition{userID, groupID}
+	m.BelongsTo["User"] = user
+	obj := design.Object{
+		"user_id":  &design.AttributeDefinition{Type: design.Integer},
+		"group_id": &design.AttributeDefinition{Type: design.Integer},
+	}
+	mt := &design.MediaTypeDefinition{
+		UserTypeDefinition: &design.UserTypeDefinition{
+			TypeName:            "Membership",
+			AttributeDefinition: &design.AttributeDefinition{Type: obj},
+		},
+	}
+	mt.Views = map[string]*design.ViewDefinition{
+		"default": {AttributeDefinition: &design.AttributeDefinition{Type: obj}, Name: "default", Parent: mt},
+	}
+	m.RenderTo[mt.TypeName] = mt
+
+	exp := "OneMembership(ctx context.Context, userID int, groupID int) (*app.Membership, error)"
+	methods := m.StorageMethods(false)
+	if sig := methods[len(methods)-1].Signature(); sig != exp {
+		t.Errorf("expected %q, got %q", exp, sig)
+	}
+
+	// the writers only write to files of a Go package
+	dir, err := ioutil.TempDir(".", "render")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "membership_fake.go")
+	w, err := gorma.NewUserFakeWriter(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteHeader("fake", "models", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Execute(&gorma.UserTypeTemplateData{UserType: m, DefaultPkg: "models", AppPkg: "app"}); err != nil {
+		t.Fatal(err)
+	}
+	code, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp = "func (f *FakeMembershipDB) OneMembership(ctx context.Context, userID int, groupID int) (*app.Membership, error) {"
+	if !strings.Contains(string(code), exp) {
+		t.Errorf("expected %q in\n%s", exp, code)
+	}
+}
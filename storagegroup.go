@@ -73,7 +73,8 @@ func (sd *StorageGroupDefinition) DependsOn() []dslengine.Root {
 }
 
 // IterateSets goes over all the definition sets of the StorageGroup: the
-// StorageGroup definition itself, each store definition, models and fields.
+// StorageGroup definition itself, each store definition, models, fields,
+// indexes, renderings, adapters and seeds.
 func (sd *StorageGroupDefinition) IterateSets(iterator dslengine.SetIterator) {
 	// First run the top level StorageGroup
 
@@ -90,9 +91,22 @@ func (sd *StorageGroupDefinition) IterateSets(iterator dslengine.SetIterator) {
 				iterator([]dslengine.Definition{bs})
 				return nil
 			})
+			model.IterateIndexes(func(i *IndexDefinition) error {
+				iterator([]dslengine.Definition{i})
+				return nil
+			})
+			for _, name := range sortedRenderNames(model.Renders) {
+				iterator([]dslengine.Definition{model.Renders[name]})
+			}
+			for _, a := range model.Adapters() {
+				iterator([]dslengine.Definition{a})
+			}
 
 			return nil
 		})
+		for _, seed := range store.Seeds {
+			iterator([]dslengine.Definition{seed})
+		}
 		return nil
 	})
 }
@@ -1,29 +1,23 @@
 package gorma
 
-import (
-	"fmt"
+import "github.com/Gys/goa/dslengine"
 
-	"github.com/Gys/goa/dslengine"
-)
-
-// Validate tests whether the StorageGroup definition is consistent.
-func (a *StorageGroupDefinition) Validate() *dslengine.ValidationErrors {
-	fmt.Println("Validating Group")
+// Validate tests whether the StorageGroup definition is consistent.  The
+// DSL engine validates the stores, models, fields and indexes of the group
+// on their own.
+func (a *StorageGroupDefinition) Validate() error {
 	verr := new(dslengine.ValidationErrors)
 	if a.Name == "" {
 		verr.Add(a, "storage group name not defined")
 	}
-	a.IterateStores(func(store *RelationalStoreDefinition) error {
-		verr.Merge(store.Validate())
-		return nil
-	})
-
-	return verr.AsError()
+	if err := verr.AsError(); err != nil {
+		return err
+	}
+	return nil
 }
 
 // Validate tests whether the RelationalStore definition is consistent.
-func (a *RelationalStoreDefinition) Validate() *dslengine.ValidationErrors {
-	fmt.Println("Validating Store")
+func (a *RelationalStoreDefinition) Validate() error {
 	verr := new(dslengine.ValidationErrors)
 	if a.Name == "" {
 		verr.Add(a, "store name not defined")
@@ -31,42 +25,72 @@ func (a *RelationalStoreDefinition) Validate() *dslengine.ValidationErrors {
 	if a.Parent == nil {
 		verr.Add(a, "missing storage group parent")
 	}
-	a.IterateModels(func(model *RelationalModelDefinition) error {
-		verr.Merge(model.Validate())
-		return nil
-	})
-
-	return verr.AsError()
+	if err := verr.AsError(); err != nil {
+		return err
+	}
+	return nil
 }
 
 // Validate tests whether the RelationalModel definition is consistent.
-func (a *RelationalModelDefinition) Validate() *dslengine.ValidationErrors {
-	fmt.Println("Validating Model")
+func (a *RelationalModelDefinition) Validate() error {
 	verr := new(dslengine.ValidationErrors)
-	if a.ModelName == "" {
-		verr.Add(a, "model name not defined")
-	}
 	if a.Parent == nil {
 		verr.Add(a, "missing relational store parent")
 	}
 	a.IterateFields(func(field *RelationalFieldDefinition) error {
-		verr.Merge(field.Validate())
+		if field.Parent == nil {
+			verr.Add(a, "field %s has no relational model parent", field.FieldName)
+		}
 		return nil
 	})
-
-	return verr.AsError()
+	if err := verr.AsError(); err != nil {
+		return err
+	}
+	return nil
 }
 
-// Validate tests whether the RelationalField definition is consistent.
-func (field *RelationalFieldDefinition) Validate() *dslengine.ValidationErrors {
-	fmt.Println("Validing Field")
+// Validate tests whether the Index definition is consistent.  It
+// implements dslengine.Validate so that the DSL engine reports indexes
+// referring to fields the model doesn't define.
+func (index *IndexDefinition) Validate() error {
 	verr := new(dslengine.ValidationErrors)
+	if index.Name == "" {
+		verr.Add(index, "index name not defined")
+	}
+	if index.Parent == nil {
+		verr.Add(index, "missing relational model parent")
+		return verr
+	}
+	if len(index.Fields) == 0 {
+		verr.Add(index, "index has no fields")
+	}
+	for _, name := range index.Fields {
+		field, ok := index.Parent.RelationalFields[name]
+		if !ok {
+			verr.Add(index, "field %s is not defined in model %s", name, index.Parent.ModelName)
+		} else if !field.IsColumn() {
+			verr.Add(index, "field %s of model %s is not a column", name, index.Parent.ModelName)
+		}
+	}
+	if index.Condition != "" && index.Parent.Dialect() == MySQL {
+		verr.Add(index, "partial indexes are not supported by MySQL")
+	}
+	if err := verr.AsError(); err != nil {
+		return err
+	}
+	return nil
+}
 
-	if field.Parent == nil {
-		verr.Add(field, "missing relational model parent")
+// Validate tests whether the RelationalField definition is consistent.  It
+// implements dslengine.Validate so that the DSL engine reports Sortable
+// fields made Nullable, whatever the order of the two in the field DSL.
+func (field *RelationalFieldDefinition) Validate() error {
+	verr := new(dslengine.ValidationErrors)
+	if field.Sortable && field.Nullable {
+		verr.Add(field, "nullable field %s can't be sortable", field.FieldName)
 	}
-	if field.FieldName == "" {
-		verr.Add(field, "field name not defined")
+	if err := verr.AsError(); err != nil {
+		return err
 	}
-	return verr.AsError()
+	return nil
 }
@@ -11,7 +11,6 @@ import (
 	"github.com/Gys/goa/design"
 	"github.com/Gys/goa/goagen/codegen"
 	"github.com/jinzhu/inflection"
-	"github.com/kr/pretty"
 )
 
 type (
@@ -22,6 +21,7 @@ type (
 		UserType      *RelationalModelDefinition
 		DefaultPkg    string
 		AppPkg        string
+		LegacyLists   bool // generate List<MediaType> without list options and error
 	}
 	// UserTypesWriter generate code for a goa application user types.
 	// User types are data structures defined in the DSL with "Type".
@@ -37,19 +37,28 @@ type (
 		*codegen.SourceFile
 		UserHelperTmpl *template.Template
 	}
+
+	// UserFakeWriter generates the in-memory fake storages of the models.
+	UserFakeWriter struct {
+		*codegen.SourceFile
+	}
 )
 
 func fieldAssignmentPayloadToModel(model *RelationalModelDefinition, ut *design.UserTypeDefinition, verpkg, v, mtype, utype string) string {
-	// Get a sortable slice of field names
-	var keys []string
-	for k := range model.RelationalFields {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
+	return payloadToModelAssignments(model, ut, v, utype, false)
+}
+
+// fieldAssignmentPayloadToModelDefaults is fieldAssignmentPayloadToModel
+// for new models: fields missing from the payload get their default value.
+func fieldAssignmentPayloadToModelDefaults(model *RelationalModelDefinition, ut *design.UserTypeDefinition, verpkg, v, mtype, utype string) string {
+	return payloadToModelAssignments(model, ut, v, utype, true)
+}
+
+func payloadToModelAssignments(model *RelationalModelDefinition, ut *design.UserTypeDefinition, v, utype string, defaults bool) string {
 
 	var fieldAssignments []string
-	for _, fname := range keys {
-		field := model.RelationalFields[fname]
+	for _, field := range model.conversionFields() {
+		fname := field.FieldName
 
 		var mpointer, upointer bool
 		mpointer = field.Nullable
@@ -63,7 +72,7 @@ func fieldAssignmentPayloadToModel(model *RelationalModelDefinition, ut *design.
 		for key := range obj {
 			gfield := obj[key]
 
-			if field.Underscore() == key || field.DatabaseFieldName == key {
+			if field.mapsTo(ut.TypeName, key) {
 				// this is our field
 				if gfield.Type.IsObject() || definition.IsPrimitivePointer(key) {
 					upointer = true
@@ -91,6 +100,17 @@ func fieldAssignmentPayloadToModel(model *RelationalModelDefinition, ut *design.
 				fa := fmt.Sprintf("\t%s.%s = %s%s.%s", utype, fname, prefix, v, codegen.Goify(key, true))
 				fieldAssignments = append(fieldAssignments, fa)
 
+				if upointer && defaults && field.Default != nil {
+					fieldAssignments = append(fieldAssignments, "} else {")
+					if mpointer {
+						fieldAssignments = append(fieldAssignments,
+							fmt.Sprintf("\td := %s", goDefault(field)),
+							fmt.Sprintf("\t%s.%s = &d", utype, fname))
+					} else {
+						fieldAssignments = append(fieldAssignments, fmt.Sprintf("\t%s.%s = %s", utype, fname, goDefault(field)))
+					}
+				}
+
 				if upointer {
 					ifa := fmt.Sprintf("}")
 					fieldAssignments = append(fieldAssignments, ifa)
@@ -134,15 +154,8 @@ func fieldAssignmentModelToType(model *RelationalModelDefinition, ut *design.Vie
 		}
 	}
 
-	// Get a sortable slice of field names
-	var keys []string
-	for k := range model.RelationalFields {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-
-	for _, fname := range keys {
-		field := model.RelationalFields[fname]
+	for _, field := range model.conversionFields() {
+		fname := field.FieldName
 
 		var mpointer, upointer bool
 		mpointer = field.Nullable
@@ -156,7 +169,7 @@ func fieldAssignmentModelToType(model *RelationalModelDefinition, ut *design.Vie
 		for key := range obj {
 			helperFuncMediaTypeNames[utype] = ut.Parent.TypeName
 			gfield := obj[key]
-			if field.Underscore() == key || field.DatabaseFieldName == key {
+			if field.mapsTo(ut.Parent.TypeName, key) {
 				// this is our field
 				if gfield.Type.IsObject() || definition.IsPrimitivePointer(key) {
 					upointer = true
@@ -222,16 +235,10 @@ func fieldAssignmentModelToType(model *RelationalModelDefinition, ut *design.Vie
 }
 
 func fieldAssignmentTypeToModel(model *RelationalModelDefinition, ut *design.UserTypeDefinition, utype, mtype string) string {
-	// Get a sortable slice of field names
-	var keys []string
-	for k := range model.RelationalFields {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
 
 	var fieldAssignments []string
-	for _, fname := range keys {
-		field := model.RelationalFields[fname]
+	for _, field := range model.conversionFields() {
+		fname := field.FieldName
 
 		var mpointer, upointer bool
 		mpointer = field.Nullable
@@ -242,7 +249,7 @@ func fieldAssignmentTypeToModel(model *RelationalModelDefinition, ut *design.Use
 		}
 		for key := range obj {
 			gfield := obj[key]
-			if field.Underscore() == key || field.DatabaseFieldName == key {
+			if field.mapsTo(ut.TypeName, key) {
 				// this is our field
 				if gfield.Type.IsObject() || definition.IsPrimitivePointer(key) {
 					upointer = true
@@ -265,59 +272,49 @@ func fieldAssignmentTypeToModel(model *RelationalModelDefinition, ut *design.Use
 	return strings.Join(fieldAssignments, "\n")
 }
 
+// viewSelect returns the columns selected by the queries building a view
+// of a media type, see RelationalModelDefinition.ViewColumns.
 func viewSelect(ut *RelationalModelDefinition, v *design.ViewDefinition) string {
-	obj := v.Type.(design.Object)
-	var fields []string
-	for name := range obj {
-		if obj[name].Type.IsPrimitive() {
-			if strings.TrimSpace(name) != "" && name != "links" {
-				bf, ok := ut.RelationalFields[codegen.Goify(name, true)]
-				if ok {
-					fields = append(fields, bf.DatabaseFieldName)
-				}
-			}
-		}
-	}
-	sort.Strings(fields)
-	return strings.Join(fields, ",")
+	return ut.ViewColumns(v)
 }
 
+// viewFields returns the columns of the model a view of a media type needs:
+// the fields its attributes map to, the primary keys and foreign keys the
+// preloads match on, and the columns ordering lists, which their cursors
+// read.
 func viewFields(ut *RelationalModelDefinition, v *design.ViewDefinition) []*RelationalFieldDefinition {
-	obj := v.Type.(design.Object)
+	obj := v.Type.ToObject()
+	needed := make(map[string]bool)
+	for _, field := range ut.sortableFields() {
+		needed[field.FieldName] = true
+	}
+	for _, bt := range ut.BelongsTo {
+		for _, key := range ut.foreignKeyFields(bt.ModelName) {
+			needed[key.FieldName] = true
+		}
+	}
 	var fields []*RelationalFieldDefinition
-	for name := range obj {
-		if obj[name].Type.IsPrimitive() {
-			if strings.TrimSpace(name) != "" && name != "links" {
-				bf, ok := ut.RelationalFields[codegen.Goify(name, true)]
-				if ok {
-					fields = append(fields, bf)
-				}
-			} else if name == "links" {
-				for _, ld := range v.Parent.Links {
-					pretty.Println(ld.Name, ld.View)
-				}
+	for _, field := range ut.columnFields() {
+		use := needed[field.FieldName] || field.PrimaryKey || isRelationshipKey(field)
+		for name := range obj {
+			if strings.TrimSpace(name) != "" && name != "links" && field.mapsTo(v.Parent.TypeName, name) {
+				use = true
 			}
 		}
+		if use {
+			fields = append(fields, field)
+		}
 	}
-
 	return fields
 }
 
+// viewFieldNames returns the addresses of the variables named after the
+// columns of viewFields, sorted.
 func viewFieldNames(ut *RelationalModelDefinition, v *design.ViewDefinition) []string {
-	obj := v.Type.(design.Object)
 	var fields []string
-	for name := range obj {
-		if obj[name].Type.IsPrimitive() {
-			if strings.TrimSpace(name) != "" && name != "links" {
-				bf, ok := ut.RelationalFields[codegen.Goify(name, true)]
-
-				if ok {
-					fields = append(fields, "&"+codegen.Goify(bf.FieldName, false))
-				}
-			}
-		}
+	for _, field := range viewFields(ut, v) {
+		fields = append(fields, "&"+codegen.Goify(field.FieldName, false))
 	}
-
 	sort.Strings(fields)
 	return fields
 }
@@ -366,6 +363,8 @@ func (w *UserTypesWriter) Execute(data *UserTypeTemplateData) error {
 	fm["famt"] = fieldAssignmentModelToType
 	fm["fatm"] = fieldAssignmentTypeToModel
 	fm["fapm"] = fieldAssignmentPayloadToModel
+	fm["fapmd"] = fieldAssignmentPayloadToModelDefaults
+	fm["famp"] = fieldAssignmentModelToPayload
 	fm["viewSelect"] = viewSelect
 	fm["viewFields"] = viewFields
 	fm["viewFieldNames"] = viewFieldNames
@@ -377,26 +376,60 @@ func (w *UserTypesWriter) Execute(data *UserTypeTemplateData) error {
 	return w.ExecuteTemplate("types", userTypeT, fm, data)
 }
 
+// NewUserFakeWriter returns a fake storage code writer.
+func NewUserFakeWriter(filename string) (*UserFakeWriter, error) {
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &UserFakeWriter{SourceFile: file}, nil
+}
+
+// Execute writes the code of the fake storage of the model.
+func (w *UserFakeWriter) Execute(data *UserTypeTemplateData) error {
+	fm := make(map[string]interface{})
+	fm["fapm"] = fieldAssignmentPayloadToModel
+	return w.ExecuteTemplate("fake", fakeT, fm, data)
+}
+
 // arrayAttribute returns the array element attribute definition.
 func arrayAttribute(a *design.AttributeDefinition) *design.AttributeDefinition {
 	return a.Type.(*design.Array).ElemType
 }
 
 type mediaTemplate struct {
-	Media    *design.MediaTypeDefinition
-	ViewName string
-	Model    *RelationalModelDefinition
-	View     *design.ViewDefinition
+	Media        *design.MediaTypeDefinition
+	ViewName     string
+	Model        *RelationalModelDefinition
+	View         *design.ViewDefinition
+	LegacyLists  bool
+	ListPreloads []string // relationships preloaded by List besides the links
+	OnePreloads  []string // relationships preloaded by One besides the usual ones
 }
 
-// {{ template "Media" (newMediaTemplate $rmt $vname $ut $vp $vpn)}}
-func newMediaTemplate(mtd *design.MediaTypeDefinition, vn string, view *design.ViewDefinition, model *RelationalModelDefinition) *mediaTemplate {
-	return &mediaTemplate{
-		Media:    mtd,
-		ViewName: vn,
-		View:     view,
-		Model:    model,
+// {{ template "Media" (newMediaTemplate $rmt $vname $view $ut $.LegacyLists)}}
+func newMediaTemplate(mtd *design.MediaTypeDefinition, vn string, view *design.ViewDefinition, model *RelationalModelDefinition, legacyLists bool) *mediaTemplate {
+	mt := &mediaTemplate{
+		Media:       mtd,
+		ViewName:    vn,
+		View:        view,
+		Model:       model,
+		LegacyLists: legacyLists,
 	}
+	links := make(map[string]bool)
+	for ln := range mtd.Links {
+		links[codegen.Goify(ln, true)] = true
+	}
+	one := model.onePreloads()
+	for _, rel := range model.ViewPreloads(mtd.TypeName, vn) {
+		if !links[rel] {
+			mt.ListPreloads = append(mt.ListPreloads, rel)
+		}
+		if !one[rel] {
+			mt.OnePreloads = append(mt.OnePreloads, rel)
+		}
+	}
+	return mt
 }
 
 const (
@@ -411,6 +444,15 @@ func (m {{$ut.ModelName}}) TableName() string {
 return "{{ $ut.Alias}}" {{ else }} return "{{ $ut.TableName }}"
 {{end}}
 }
+
+{{ $ut.KeyGenerators }}
+// Validate checks the model against the validations of the payloads it is
+// built from.
+func (m *{{$ut.ModelName}}) Validate() (err error) {
+{{ $ut.ValidationCode }}
+	return
+}
+
 // {{$ut.ModelName}}DB is the implementation of the storage interface for
 // {{$ut.ModelName}}.
 type {{$ut.ModelName}}DB struct {
@@ -432,27 +474,8 @@ func (m *{{$ut.ModelName}}DB) DB() interface{} {
 
 // {{$ut.ModelName}}Storage represents the storage interface.
 type {{$ut.ModelName}}Storage interface {
-	DB() interface{}
-	List(ctx context.Context{{ if $ut.DynamicTableName}}, tableName string{{ end }}) ([]*{{$ut.ModelName}}, error)
-	Get(ctx context.Context{{ if $ut.DynamicTableName }}, tableName string{{ end }}, {{$ut.PKAttributes}}) (*{{$ut.ModelName}}, error)
-	Add(ctx context.Context{{ if $ut.DynamicTableName }}, tableName string{{ end }}, {{$ut.LowerName}} *{{$ut.ModelName}}) (error)
-	Update(ctx context.Context{{ if $ut.DynamicTableName }}, tableName string{{ end }}, {{$ut.LowerName}} *{{$ut.ModelName}}) (error)
-	Delete(ctx context.Context{{ if $ut.DynamicTableName }}, tableName string{{ end }}, {{ $ut.PKAttributes}}) (error)
-{{range $rname, $rmt := $ut.RenderTo}}{{/*
-
-*/}}{{range $vname, $view := $rmt.Views}}{{ $mtd := $ut.Project $rname $vname }}
-	List{{goify $rmt.TypeName true}}{{if not (eq $vname "default")}}{{goify $vname true}}{{end}} (ctx context.Context{{ if $ut.DynamicTableName}}, tableName string{{ end }}{{/*
-*/}}{{range $nm, $bt := $ut.BelongsTo}}, {{goify (printf "%s%s" $bt.ModelName "ID") false}} int{{end}}) []*app.{{goify $rmt.TypeName true}}{{if not (eq $vname "default")}}{{goify $vname true}}{{end}}
-	One{{goify $rmt.TypeName true}}{{if not (eq $vname "default")}}{{goify $vname true}}{{end}} (ctx context.Context{{ if $ut.DynamicTableName}}, tableName string{{ end }}{{/*
-*/}}, {{$ut.PKAttributes}}{{range $nm, $bt := $ut.BelongsTo}},{{goify (printf "%s%s" $bt.ModelName "ID") false}} int{{end}}){{/*
-*/}} (*app.{{goify $rmt.TypeName true}}{{if not (eq $vname "default")}}{{goify $vname true}}{{end}}, error)
-{{end}}{{/*
-
-*/}}{{end}}
-{{range $bfn, $bf := $ut.BuiltFrom}}
-	UpdateFrom{{$bfn}}(ctx context.Context{{ if $ut.DynamicTableName}}, tableName string{{ end }},payload *app.{{goify $bfn true}}, {{$ut.PKAttributes}}) error
-{{end}}
-}
+{{ range $ut.StorageMethods $.LegacyLists }}	{{ .Signature }}
+{{ end }}}
 
 // TableName overrides the table name settings in Gorm to force a specific table name
 // in the database.
@@ -466,24 +489,105 @@ func (m *{{$ut.ModelName}}DB) TableName() string {
 // Belongs To Relationships
 
 // {{$ut.ModelName}}FilterBy{{$bt.ModelName}} is a gorm filter for a Belongs To relationship.
-func {{$ut.ModelName}}FilterBy{{$bt.ModelName}}({{goify (printf "%s%s" $bt.ModelName "ID") false}} {{ goDatatypeByModel $ut $bt.ModelName }}, originaldb *gorm.DB) func(db *gorm.DB) *gorm.DB {
-
-{{ range $l, $pk := $ut.PrimaryKeys }}
-	{{ if eq $pk.Datatype "uuid" }}
-	if {{goify (printf "%s%s" $bt.ModelName "ID") false}}.String() != "" {
-{{ else }}
-	if {{goify (printf "%s%s" $bt.ModelName "ID") false}} > 0 {
-{{ end }}
-{{ end }}
+func {{$ut.ModelName}}FilterBy{{$bt.ModelName}}({{$ut.BelongsToAttributes $bt.ModelName}}, originaldb *gorm.DB) func(db *gorm.DB) *gorm.DB {
+	if {{$ut.BelongsToGuard $bt.ModelName}} {
 		return func(db *gorm.DB) *gorm.DB {
-			return db.Where("{{if $bt.RelationalFields.ID.DatabaseFieldName}}{{ if ne $bt.RelationalFields.ID.DatabaseFieldName "id" }}{{$bt.RelationalFields.ID.DatabaseFieldName}} = ?", {{goify (printf "%s%s" $bt.ModelName "ID") false}}){{else}}{{$bt.Underscore}}_id = ?", {{goify (printf "%s%s" $bt.ModelName "ID") false}}){{end}}
-			{{ else }}{{$bt.Underscore}}_id = ?", {{goify (printf "%s%s" $bt.ModelName "ID") false}}){{ end }}
+			return db.Where({{printf "%q" ($ut.BelongsToWhere $bt.ModelName)}}, {{$ut.BelongsToFields $bt.ModelName}})
 		}
 	}
 	return func(db *gorm.DB) *gorm.DB { return db }
 }
 {{end}}
 
+// {{$ut.ModelName}}Filter selects the {{$ut.ModelName}} records of a list, nil fields don't filter.
+type {{$ut.ModelName}}Filter struct {
+{{ $ut.FilterDefinition }}
+}
+
+// {{$ut.ModelName}}FilterByFields is a gorm filter selecting the records matching the filter.
+func {{$ut.ModelName}}FilterByFields(filter *{{$ut.ModelName}}Filter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter == nil {
+			return db
+		}
+{{ $ut.FilterCode }}
+		return db
+	}
+}
+
+// {{$ut.ModelName}}ListOptions pages, orders and filters the lists of {{$ut.ModelName}}.
+type {{$ut.ModelName}}ListOptions struct {
+	// Limit is the maximum number of records of a page, 0 lists all of them.
+	Limit int
+	// Offset skips the first records of the list.
+	Offset int
+	// After is the NextCursor of the previous page, it continues the list
+	// after that page (keyset pagination).  Offset is ignored.
+	After string
+	// OrderBy lists the columns ordering the list, prefixed with "-" for a
+	// descending order.  The primary key completes the order.
+	OrderBy []string
+	// Filter selects the records of the list.
+	Filter {{$ut.ModelName}}Filter
+}
+
+// listColumn returns the quoted column and a pointer to the field stored in
+// the named column, or an empty column when the column can't order lists.
+func (m *{{$ut.ModelName}}) listColumn(name string) (string, interface{}) {
+	switch name {
+{{ $ut.ListColumns }}
+	}
+	return "", nil
+}
+
+// list loads a page of {{$ut.ModelName}} records from db.
+func (m *{{$ut.ModelName}}DB) list(db *gorm.DB, opts *{{$ut.ModelName}}ListOptions) ([]*{{$ut.ModelName}}, *ListPage, error) {
+	if opts == nil {
+		opts = &{{$ut.ModelName}}ListOptions{}
+	}
+	order, err := listOrder(&{{$ut.ModelName}}{}, opts.OrderBy, {{$ut.ListKeys}})
+	if err != nil {
+		return nil, nil, validationError("{{$ut.ModelName}}", err)
+	}
+	db = db.Scopes({{$ut.ModelName}}FilterByFields(&opts.Filter))
+
+	page := &ListPage{}
+	var after []interface{}
+	if opts.After != "" {
+		if after, err = decodeCursor(&{{$ut.ModelName}}{}, order, opts.After); err != nil {
+			return nil, nil, validationError("{{$ut.ModelName}}", err)
+		}
+	}
+	// the records of a partial list are counted, a full list holds them all
+	partial := opts.After != "" || opts.Limit > 0 || opts.Offset > 0
+	if partial {
+		if err = db.Model(&{{$ut.ModelName}}{}).Count(&page.Total).Error; err != nil {
+			return nil, nil, {{$ut.StorageError "err"}}
+		}
+	}
+	if opts.After == "" && opts.Offset > 0 {
+		db = db.Offset(opts.Offset)
+	}
+	if opts.Limit > 0 {
+		db = db.Limit(opts.Limit)
+	}
+
+	var objs []*{{$ut.ModelName}}
+	err = db.Scopes(listScope(order, after)).Find(&objs).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, nil, {{$ut.StorageError "err"}}
+	}
+	if !partial {
+		page.Total = len(objs)
+	}
+	if opts.Limit > 0 && len(objs) == opts.Limit {
+		if page.NextCursor, err = encodeCursor(objs[len(objs)-1], order); err != nil {
+			return nil, nil, err
+		}
+	}
+	return objs, page, nil
+}
+
 // CRUD Functions
 
 // Get returns a single {{$ut.ModelName}} as a Database Model
@@ -492,42 +596,37 @@ func (m *{{$ut.ModelName}}DB) Get(ctx context.Context{{ if $ut.DynamicTableName}
 	defer goa.MeasureSince([]string{"goa","db","{{goify $ut.ModelName false}}", "get"}, time.Now())
 
 	var native {{$ut.ModelName}}
-	err := m.Db.Table({{ if $ut.DynamicTableName }}tableName{{else}}m.TableName(){{ end }}).Where("{{$ut.PKWhere}}",{{$ut.PKWhereFields}} ).Find(&native).Error
-	if err ==  gorm.ErrRecordNotFound {
-		return nil, err
+	err := m.Db.Table({{ if $ut.DynamicTableName }}tableName{{else}}m.TableName(){{ end }}).Where({{printf "%q" $ut.PKWhere}},{{$ut.PKWhereFields}} ).Find(&native).Error
+	if err != nil {
+		return nil, {{$ut.StorageError "err"}}
 	}
-	{{ if $ut.Cached }}go m.cache.Set(strconv.Itoa(native.ID), &native, cache.DefaultExpiration)
+	{{ if $ut.Cached }}go m.cache.Set({{$ut.CacheKey "native"}}, &native, cache.DefaultExpiration)
 	{{end}}
-	return &native, err
+	return &native, nil
 }
 
-// List returns an array of {{$ut.ModelName}}
-func (m *{{$ut.ModelName}}DB) List(ctx context.Context{{ if $ut.DynamicTableName}}, tableName string{{ end }}) ([]*{{$ut.ModelName}}, error) {
+// List returns a page of {{$ut.ModelName}}, all of them when opts is nil.
+func (m *{{$ut.ModelName}}DB) List(ctx context.Context{{ if $ut.DynamicTableName}}, tableName string{{ end }}, opts *{{$ut.ModelName}}ListOptions) ([]*{{$ut.ModelName}}, *ListPage, error) {
 	defer goa.MeasureSince([]string{"goa","db","{{goify $ut.ModelName false}}", "list"}, time.Now())
 
-	var objs []*{{$ut.ModelName}}
-	err := m.Db.Table({{ if $ut.DynamicTableName }}tableName{{else}}m.TableName(){{ end }}).Find(&objs).Error
-	if err != nil && err !=  gorm.ErrRecordNotFound {
-		return nil, err
-	}
-
-	return objs, nil
+	return m.list(m.Db.Table({{ if $ut.DynamicTableName }}tableName{{else}}m.TableName(){{ end }}), opts)
 }
 
 // Add creates a new record.
 func (m *{{$ut.ModelName}}DB) Add(ctx context.Context{{ if $ut.DynamicTableName }}, tableName string{{ end }}, model *{{$ut.ModelName}}) (error) {
 	defer goa.MeasureSince([]string{"goa","db","{{goify $ut.ModelName false}}", "add"}, time.Now())
 
-{{ range $l, $pk := $ut.PrimaryKeys }}
-	{{ if eq $pk.Datatype "uuid" }}model.{{$pk.FieldName}} = uuid.Must(uuid.NewV4()){{ end }}
-{{ end }}
+	if err := model.Validate(); err != nil {
+		return validationError("{{$ut.ModelName}}", err)
+	}
+{{ $ut.KeyGeneration }}
 	err := m.Db{{ if $ut.DynamicTableName }}.Table(tableName){{ end }}.Create(model).Error
 	if err != nil {
 		goa.LogError(ctx, "error adding {{$ut.ModelName}}", "error", err.Error())
-		return err
+		return {{$ut.StorageError "err"}}
 	}
 	{{ if $ut.Cached }}
-	go m.cache.Set(strconv.Itoa(model.ID), model, cache.DefaultExpiration) {{ end }}
+	go m.cache.Set({{$ut.CacheKey "model"}}, model, cache.DefaultExpiration) {{ end }}
 	return nil
 }
 
@@ -535,33 +634,40 @@ func (m *{{$ut.ModelName}}DB) Add(ctx context.Context{{ if $ut.DynamicTableName
 func (m *{{$ut.ModelName}}DB) Update(ctx context.Context{{ if $ut.DynamicTableName }}, tableName string{{ end }}, model *{{$ut.ModelName}}) error {
 	defer goa.MeasureSince([]string{"goa","db","{{goify $ut.ModelName false}}", "update"}, time.Now())
 
+	if err := model.Validate(); err != nil {
+		return validationError("{{$ut.ModelName}}", err)
+	}
 	obj, err := m.Get(ctx{{ if $ut.DynamicTableName }}, tableName{{ end }}, {{$ut.PKUpdateFields "model"}})
 	if err != nil {
 		goa.LogError(ctx, "error updating {{$ut.ModelName}}", "error", err.Error())
 		return  err
 	}
 	err = m.Db{{ if $ut.DynamicTableName }}.Table(tableName){{ end }}.Model(obj).Updates(model).Error
+	if err != nil {
+		goa.LogError(ctx, "error updating {{$ut.ModelName}}", "error", err.Error())
+		return {{$ut.StorageError "err"}}
+	}
 	{{ if $ut.Cached }}go func(){
-		m.cache.Set(strconv.Itoa(model.ID), obj, cache.DefaultExpiration)
+		m.cache.Set({{$ut.CacheKey "model"}}, obj, cache.DefaultExpiration)
 	}()
 	{{ end }}
-	return err
+	return nil
 }
 
 // Delete removes a single record.
 func (m *{{$ut.ModelName}}DB) Delete(ctx context.Context{{ if $ut.DynamicTableName }}, tableName string{{ end }}, {{$ut.PKAttributes}})  error {
 	defer goa.MeasureSince([]string{"goa","db","{{goify $ut.ModelName false}}", "delete"}, time.Now())
 
-	var obj {{$ut.ModelName}}{{ $l := len $ut.PrimaryKeys }}
-	{{ if eq $l 1 }}
-	err := m.Db{{ if $ut.DynamicTableName }}.Table(tableName){{ end }}.Delete(&obj, {{$ut.PKWhereFields}}).Error
-	{{ else  }}err := m.Db{{ if $ut.DynamicTableName }}.Table(tableName){{ end }}.Delete(&obj).Where("{{$ut.PKWhere}}", {{$ut.PKWhereFields}}).Error
-	{{ end }}
-	if err != nil {
+	var obj {{$ut.ModelName}}
+	res := m.Db{{ if $ut.DynamicTableName }}.Table(tableName){{ end }}.Where({{printf "%q" $ut.PKWhere}}, {{$ut.PKWhereFields}}).Delete(&obj)
+	if err := res.Error; err != nil {
 		goa.LogError(ctx, "error deleting {{$ut.ModelName}}", "error", err.Error())
-		return  err
+		return  {{$ut.StorageError "err"}}
+	}
+	if res.RowsAffected == 0 {
+		return {{$ut.StorageError "gorm.ErrRecordNotFound"}}
 	}
-	{{ if $ut.Cached }} go m.cache.Delete(strconv.Itoa(id)) {{ end }}
+	{{ if $ut.Cached }} go m.cache.Delete({{$ut.CacheKey ""}}) {{ end }}
 	return  nil
 }
 
@@ -570,7 +676,7 @@ func (m *{{$ut.ModelName}}DB) Delete(ctx context.Context{{ if $ut.DynamicTableNa
 // only copying the non-nil fields from the source.
 func {{$ut.ModelName}}From{{$bfn}}(payload *app.{{goify $bfn true}}) *{{$ut.ModelName}} {
 	{{$ut.LowerName}} := &{{$ut.ModelName}}{}
- 	{{ fapm $ut $bf "app" "payload" "payload" $ut.LowerName}}
+ 	{{ fapmd $ut $bf "app" "payload" "payload" $ut.LowerName}}
 
  	 return {{$ut.LowerName}}
 }
@@ -580,19 +686,1000 @@ func (m *{{$ut.ModelName}}DB)UpdateFrom{{$bfn}}(ctx context.Context{{ if $ut.Dyn
 	defer goa.MeasureSince([]string{"goa","db","{{goify $ut.ModelName false}}", "updatefrom{{goify $bfn false}}"}, time.Now())
 
 	var obj {{$ut.ModelName}}
-	 err := m.Db.Table({{ if $ut.DynamicTableName }}tableName{{else}}m.TableName(){{ end }}).Where("{{$ut.PKWhere}}",{{$ut.PKWhereFields}} ).Find(&obj).Error
+	 err := m.Db.Table({{ if $ut.DynamicTableName }}tableName{{else}}m.TableName(){{ end }}).Where({{printf "%q" $ut.PKWhere}},{{$ut.PKWhereFields}} ).Find(&obj).Error
 	if err != nil {
 		goa.LogError(ctx, "error retrieving {{$ut.ModelName}}", "error", err.Error())
-		return  err
+		return  {{$ut.StorageError "err"}}
 	}
  	{{ fapm $ut $bf "app" "payload" "payload" "obj"}}
 
+	if err = obj.Validate(); err != nil {
+		return validationError("{{$ut.ModelName}}", err)
+	}
 	err = m.Db.Save(&obj).Error
- 	 return err
+ 	 return {{$ut.StorageError "err"}}
+}
+{{ if $ut.HasPayloadConversion $bfn }}
+// {{$ut.ModelName}}To{{$bfn}} converts the model to a {{goify $bfn true}}, the reverse
+// of {{$ut.ModelName}}From{{$bfn}}.
+func (m *{{$ut.ModelName}}) {{$ut.ModelName}}To{{$bfn}}() *app.{{goify $bfn true}} {
+	payload := &app.{{goify $bfn true}}{}
+	{{ famp $ut $bf "m" "payload" }}
+	return payload
+}
+{{ end }}{{ end  }}
+
+
+`
+
+	// listT generates the helpers shared by the list methods of the models.
+	listT = `// ListPage describes the page of records returned by a list method.
+type ListPage struct {
+	// Total is the number of records matching the filters of the list,
+	// on all its pages.
+	Total int
+	// NextCursor continues the list after the page, see the After list
+	// option.  It is empty on the last page.
+	NextCursor string
+}
+
+// listColumn is a column ordering a list.
+type listColumn struct {
+	name   string // name of the column in the list options
+	column string // quoted name of the column in queries
+	desc   bool
+}
+
+// listable is implemented by the models, listColumn whitelists the columns
+// ordering their lists.
+type listable interface {
+	listColumn(name string) (string, interface{})
+}
+
+// listOrder returns the columns ordering a list: the orderBy columns,
+// descending when prefixed with "-", followed by the key columns that make
+// the order total.
+func listOrder(m listable, orderBy, keys []string) ([]listColumn, error) {
+	var order []listColumn
+	seen := make(map[string]bool)
+	for _, name := range orderBy {
+		desc := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+		column, _ := m.listColumn(name)
+		if column == "" {
+			return nil, fmt.Errorf("can't order by %q", name)
+		}
+		if !seen[name] {
+			order = append(order, listColumn{name: name, column: column, desc: desc})
+			seen[name] = true
+		}
+	}
+	for _, name := range keys {
+		if !seen[name] {
+			column, _ := m.listColumn(name)
+			order = append(order, listColumn{name: name, column: column})
+		}
+	}
+	return order, nil
+}
+
+// listScope returns the gorm scope ordering a list and continuing it after
+// the values of a cursor, if any.
+func listScope(order []listColumn, after []interface{}) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if after != nil {
+			var or []string
+			var args []interface{}
+			for i, c := range order {
+				var and []string
+				for j, p := range order[:i] {
+					and = append(and, p.column+" = ?")
+					args = append(args, after[j])
+				}
+				if c.desc {
+					and = append(and, c.column+" < ?")
+				} else {
+					and = append(and, c.column+" > ?")
+				}
+				args = append(args, after[i])
+				or = append(or, "("+strings.Join(and, " AND ")+")")
+			}
+			db = db.Where(strings.Join(or, " OR "), args...)
+		}
+		for _, c := range order {
+			if c.desc {
+				db = db.Order(c.column + " DESC")
+			} else {
+				db = db.Order(c.column)
+			}
+		}
+		return db
+	}
+}
+
+// encodeCursor returns the cursor continuing a list after the model.
+func encodeCursor(m listable, order []listColumn) (string, error) {
+	values := make([]interface{}, len(order))
+	for i, c := range order {
+		_, values[i] = m.listColumn(c.name)
+	}
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodeCursor returns the values of the order columns saved in a cursor,
+// decoded into the fields of m.
+func decodeCursor(m listable, order []listColumn, cursor string) ([]interface{}, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil || len(raw) != len(order) {
+		return nil, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	values := make([]interface{}, len(order))
+	for i, c := range order {
+		_, field := m.listColumn(c.name)
+		if err := json.Unmarshal(raw[i], field); err != nil {
+			return nil, fmt.Errorf("invalid cursor %q", cursor)
+		}
+		values[i] = field
+	}
+	return values, nil
+}
+`
+
+	// errorsT generates the errors returned by the storages of the models.
+	errorsT = `var (
+	// ErrNotFound is returned when the record doesn't exist.
+	ErrNotFound = errors.New("record not found")
+	// ErrConflict is returned when a record violates a unique constraint.
+	ErrConflict = errors.New("record conflicts with an existing record")
+	// ErrForeignKey is returned when a record references a missing record,
+	// or when a record still referenced is deleted.
+	ErrForeignKey = errors.New("foreign key violation")
+	// ErrValidation is returned when a record or the options of a list are
+	// invalid.
+	ErrValidation = errors.New("validation failed")
+)
+
+// Error is the error returned by the storages.  It wraps the error of the
+// database or of the validation, and errors.Is matches it with its Kind.
+type Error struct {
+	// Kind is ErrNotFound, ErrConflict, ErrForeignKey or ErrValidation.
+	Kind error
+	// Model is the name of the model of the storage.
+	Model string
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s: %s", e.Model, e.Kind, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is returns true if target is the kind of the error.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// storageError maps an error of the database of the given dialect to an
+// Error, errors of other kinds are returned as is.
+func storageError(dialect, model string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	if kind := errorKind(dialect, err); kind != nil {
+		return &Error{Kind: kind, Model: model, Err: err}
+	}
+	return err
+}
+
+// validationError wraps an error of a validation in an Error.
+func validationError(model string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: ErrValidation, Model: model, Err: err}
+}
+
+// errorKind returns the kind of an error of the database, nil when it is
+// none of the storage errors.
+func errorKind(dialect string, err error) error {
+	if gorm.IsRecordNotFoundError(err) {
+		return ErrNotFound
+	}
+	switch dialect {
+	case "postgres":
+		switch postgresCode(err) {
+		case "23505":
+			return ErrConflict
+		case "23503":
+			return ErrForeignKey
+		}
+	case "mysql":
+		switch mysqlCode(err) {
+		case 1062:
+			return ErrConflict
+		case 1451, 1452:
+			return ErrForeignKey
+		}
+	}
+	// SQLite errors are recognized whatever the dialect of the store, the
+	// stores may be tested against SQLite databases.
+	msg := rootError(err).Error()
+	switch {
+	case strings.HasPrefix(msg, "UNIQUE constraint failed"):
+		return ErrConflict
+	case strings.HasPrefix(msg, "FOREIGN KEY constraint failed"):
+		return ErrForeignKey
+	}
+	return nil
+}
+
+// isRetryable returns true if a transaction that failed with an error of
+// the database may succeed when run again: after a serialization failure,
+// a deadlock or a lock timeout.
+func isRetryable(dialect string, err error) bool {
+	switch dialect {
+	case "postgres":
+		code := postgresCode(err)
+		if code == "40001" || code == "40P01" {
+			return true
+		}
+	case "mysql":
+		code := mysqlCode(err)
+		if code == 1213 || code == 1205 {
+			return true
+		}
+	}
+	return strings.HasPrefix(rootError(err).Error(), "database is locked")
+}
+
+// postgresCode returns the SQLSTATE code of a Postgres error, empty when
+// err isn't one.  The errors of the lib/pq and pgx drivers both have a
+// SQLState method.
+func postgresCode(err error) string {
+	var stateErr interface{ SQLState() string }
+	if errors.As(err, &stateErr) {
+		return stateErr.SQLState()
+	}
+	return ""
+}
+
+// mysqlCode returns the error number of a MySQL error, 0 when err isn't
+// one.
+func mysqlCode(err error) int {
+	var code int
+	if _, scanErr := fmt.Sscanf(rootError(err).Error(), "Error %d", &code); scanErr != nil {
+		return 0
+	}
+	return code
+}
+
+// rootError returns the innermost error wrapped by err.
+func rootError(err error) error {
+	for {
+		wrapped := errors.Unwrap(err)
+		if wrapped == nil {
+			return err
+		}
+		err = wrapped
+	}
+}
+`
+
+	// txT generates the transaction helpers of the repositories.
+	txT = `// RetryPolicy says how often a transaction failing on a serialization
+// failure or a deadlock is run again.
+type RetryPolicy struct {
+	// MaxAttempts is the number of times the transaction is run at most,
+	// 0 and 1 don't retry.
+	MaxAttempts int
+	// Backoff is the wait before the second attempt, doubled before each
+	// further attempt.
+	Backoff time.Duration
+}
+
+// DefaultRetryPolicy runs transactions up to three times.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, Backoff: 20 * time.Millisecond}
+
+// runTx runs fn in a transaction of db, committed when fn returns nil and
+// rolled back otherwise.  Failed transactions are run again according to
+// the policy.
+func runTx(ctx context.Context, db *gorm.DB, dialect string, policy RetryPolicy, fn func(tx *gorm.DB) error) error {
+	backoff := policy.Backoff
+	for attempt := 1; ; attempt++ {
+		err := runTxOnce(ctx, db, fn)
+		if err == nil || attempt >= policy.MaxAttempts || !isRetryable(dialect, err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
 }
-{{ end  }}
 
+// runTxOnce runs fn in a transaction of db.
+func runTxOnce(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	tx := db.BeginTx(ctx, nil)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
 
+// runSavepoint runs fn in a savepoint of the transaction tx, nested depth
+// levels deep.  The transaction is rolled back to the savepoint when fn
+// fails.
+func runSavepoint(tx *gorm.DB, depth int, fn func() error) error {
+	name := fmt.Sprintf("gorma_savepoint_%d", depth)
+	if err := tx.Exec("SAVEPOINT " + name).Error; err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		if rbErr := tx.Exec("ROLLBACK TO SAVEPOINT " + name).Error; rbErr != nil {
+			return rbErr
+		}
+		return err
+	}
+	return tx.Exec("RELEASE SAVEPOINT " + name).Error
+}
+`
+
+	// repositoryT generates the repository of a store.
+	// template input: *RelationalStoreDefinition
+	repositoryT = `{{$repo := .RepositoryName}}{{$iface := .StorageName}}// {{$iface}} gives access to the storages of the models of the {{.Name}}
+// store, it is implemented by {{$repo}}.
+type {{$iface}} interface {
+{{range $name, $model := .RelationalModels}}	{{$model.ModelName}}() {{$model.ModelName}}Storage
+{{end}}	WithTx(ctx context.Context, fn func(tx {{$iface}}) error) error
+	Ping(ctx context.Context) error
+}
+
+// {{$repo}} holds the storages of the models of the {{.Name}} store, its
+// WithTx method runs them in a transaction.
+type {{$repo}} struct {
+	Db *gorm.DB
+	// Retry is the policy of the transactions run by WithTx.
+	Retry RetryPolicy
+
+	pool  *gorm.DB // database of the repository created by New{{$repo}}
+	depth int      // nesting of the transactions, 0 out of transactions
+{{range $name, $model := .RelationalModels}}	{{$model.LowerName}}DB {{$model.ModelName}}Storage
+{{end}}}
+
+var _ {{$iface}} = (*{{$repo}})(nil)
+
+// New{{$repo}} creates the storages of the models of the {{.Name}} store.
+func New{{$repo}}(db *gorm.DB) *{{$repo}} {
+	return new{{$repo}}(db, db, DefaultRetryPolicy, 0)
+}
+
+// New{{$repo}}Tx creates the storages of the models of the {{.Name}} store
+// using tx, a transaction begun on the pool database.  WithTx runs its
+// functions in savepoints of tx, the caller commits or rolls back tx.
+func New{{$repo}}Tx(pool, tx *gorm.DB) *{{$repo}} {
+	return new{{$repo}}(pool, tx, DefaultRetryPolicy, 1)
+}
+
+func new{{$repo}}(pool, db *gorm.DB, retry RetryPolicy, depth int) *{{$repo}} {
+	return &{{$repo}}{
+		Db:    db,
+		Retry: retry,
+		pool:  pool,
+		depth: depth,
+{{range $name, $model := .RelationalModels}}		{{$model.LowerName}}DB: New{{$model.ModelName}}DB(db),
+{{end}}	}
+}
+{{range $name, $model := .RelationalModels}}
+// {{$model.ModelName}} returns the storage of the {{$model.ModelName}} model.
+func (r *{{$repo}}) {{$model.ModelName}}() {{$model.ModelName}}Storage {
+	return r.{{$model.LowerName}}DB
+}
+{{end}}
+// WithTx runs fn with a repository whose storages use a transaction,
+// committed when fn returns nil and rolled back otherwise.  Transactions
+// failing on a serialization failure or a deadlock are run again according
+// to the Retry policy, so fn may be called more than once.  Called on the
+// repository of a transaction, WithTx runs fn in a savepoint: when fn
+// fails, only its changes are rolled back.
+func (r *{{$repo}}) WithTx(ctx context.Context, fn func(tx {{$iface}}) error) error {
+	if r.depth > 0 {
+		return runSavepoint(r.Db, r.depth, func() error {
+			return fn(new{{$repo}}(r.pool, r.Db, r.Retry, r.depth+1))
+		})
+	}
+	return runTx(ctx, r.Db, {{printf "%q" .Type}}, r.Retry, func(tx *gorm.DB) error {
+		return fn(new{{$repo}}(r.pool, tx, r.Retry, 1))
+	})
+}
+
+// Ping checks that the database of the repository is reachable.
+func (r *{{$repo}}) Ping(ctx context.Context) error {
+	return r.pool.DB().PingContext(ctx)
+}
+`
+
+	// fakeHelpersT generates the helpers shared by the fake storages.
+	fakeHelpersT = `// fakeCompare compares the values pointed to by a and b, of the same
+// type, the way the database orders them: it returns -1, 0 or 1.
+func fakeCompare(a, b interface{}) int {
+	var less, greater bool
+	switch a := a.(type) {
+	case *bool:
+		b := *b.(*bool)
+		less, greater = !*a && b, *a && !b
+	case *int:
+		b := *b.(*int)
+		less, greater = *a < b, *a > b
+	case *int64:
+		b := *b.(*int64)
+		less, greater = *a < b, *a > b
+	case *float32:
+		b := *b.(*float32)
+		less, greater = *a < b, *a > b
+	case *float64:
+		b := *b.(*float64)
+		less, greater = *a < b, *a > b
+	case *string:
+		b := *b.(*string)
+		less, greater = *a < b, *a > b
+	case *uuid.UUID:
+		c := bytes.Compare(a[:], b.(*uuid.UUID)[:])
+		less, greater = c < 0, c > 0
+	case *time.Time:
+		b := *b.(*time.Time)
+		less, greater = a.Before(b), a.After(b)
+	default:
+		panic(fmt.Sprintf("can't compare %T values", a))
+	}
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
+
+// fakeLess reports whether the record a comes before b in the order.
+func fakeLess(a, b listable, order []listColumn) bool {
+	for _, c := range order {
+		_, av := a.listColumn(c.name)
+		_, bv := b.listColumn(c.name)
+		if cmp := fakeCompare(av, bv); cmp != 0 {
+			return (cmp < 0) != c.desc
+		}
+	}
+	return false
+}
+
+// fakeAfter reports whether the record comes after the cursor values in
+// the order.
+func fakeAfter(m listable, order []listColumn, after []interface{}) bool {
+	for i, c := range order {
+		_, v := m.listColumn(c.name)
+		if cmp := fakeCompare(v, after[i]); cmp != 0 {
+			return (cmp > 0) != c.desc
+		}
+	}
+	return false
+}
+
+// fakeLike reports whether s matches the pattern of a LIKE condition, case
+// sensitively.
+func fakeLike(s, pattern string) bool {
+	var expr strings.Builder
+	expr.WriteString("(?s)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			expr.WriteString(".*")
+		case '_':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String()).MatchString(s)
+}
+`
+
+	// fakeT generates the in-memory fake storage of a model.
+	// template input: UserTypeTemplateData
+	fakeT = `{{$ut := .UserType}}{{$m := $ut.ModelName}}// Fake{{$m}}DB is an in-memory implementation of {{$m}}Storage for tests,
+// safe for concurrent use.  Keys, timestamps and soft deletes behave like
+// {{$m}}DB, associations aren't loaded.
+type Fake{{$m}}DB struct {
+	mu      sync.Mutex
+	records map[string]*{{$m}}
+	{{ $ut.FakeCounters }}
+}
+
+var _ {{$m}}Storage = (*Fake{{$m}}DB)(nil)
+
+// NewFake{{$m}}DB creates an empty fake storage.
+func NewFake{{$m}}DB() *Fake{{$m}}DB {
+	return &Fake{{$m}}DB{records: make(map[string]*{{$m}})}
+}
+
+// DB returns nil, the fake storage has no database.
+func (f *Fake{{$m}}DB) DB() interface{} {
+	return nil
+}
+
+// matches reports whether the record is selected by the filter.
+func (filter *{{$m}}Filter) matches(m *{{$m}}) bool {
+{{ $ut.FakeFilterCode }}
+	return true
+}
+
+// get returns the stored record, nil if there is none{{if $ut.SoftDelete}} or it is deleted{{end}}.
+// f.mu must be held.
+func (f *Fake{{$m}}DB) get(key string) *{{$m}} {
+	obj, ok := f.records[key]
+	if !ok {{if $ut.SoftDelete}}|| obj.DeletedAt != nil {{end}}{
+		return nil
+	}
+	return obj
+}
+
+// list returns a page of copies of the records selected by match and the
+// filter of the options.
+func (f *Fake{{$m}}DB) list(match func(m *{{$m}}) bool, opts *{{$m}}ListOptions) ([]*{{$m}}, *ListPage, error) {
+	if opts == nil {
+		opts = &{{$m}}ListOptions{}
+	}
+	order, err := listOrder(&{{$m}}{}, opts.OrderBy, {{$ut.ListKeys}})
+	if err != nil {
+		return nil, nil, validationError("{{$m}}", err)
+	}
+	var after []interface{}
+	if opts.After != "" {
+		if after, err = decodeCursor(&{{$m}}{}, order, opts.After); err != nil {
+			return nil, nil, validationError("{{$m}}", err)
+		}
+	}
+
+	var objs []*{{$m}}
+	f.mu.Lock()
+	for key := range f.records {
+		if obj := f.get(key); obj != nil && match(obj) && opts.Filter.matches(obj) {
+			native := *obj
+			objs = append(objs, &native)
+		}
+	}
+	f.mu.Unlock()
+	sort.Slice(objs, func(i, j int) bool { return fakeLess(objs[i], objs[j], order) })
+
+	page := &ListPage{Total: len(objs)}
+	if after != nil {
+		objs = objs[sort.Search(len(objs), func(i int) bool { return fakeAfter(objs[i], order, after) }):]
+	} else {
+		if opts.Offset >= len(objs) {
+			objs = nil
+		} else {
+			objs = objs[opts.Offset:]
+		}
+	}
+	if opts.Limit > 0 && len(objs) >= opts.Limit {
+		objs = objs[:opts.Limit]
+		if page.NextCursor, err = encodeCursor(objs[len(objs)-1], order); err != nil {
+			return nil, nil, err
+		}
+	}
+	return objs, page, nil
+}
+
+// Get returns a copy of a single {{$m}}.
+func (f *Fake{{$m}}DB) Get(ctx context.Context{{ if $ut.DynamicTableName}}, tableName string{{ end }}, {{$ut.PKAttributes}}) (*{{$m}}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj := f.get({{$ut.CacheKey ""}})
+	if obj == nil {
+		return nil, {{$ut.StorageError "gorm.ErrRecordNotFound"}}
+	}
+	native := *obj
+	return &native, nil
+}
+
+// List returns a page of {{$m}}, all of them when opts is nil.
+func (f *Fake{{$m}}DB) List(ctx context.Context{{ if $ut.DynamicTableName}}, tableName string{{ end }}, opts *{{$m}}ListOptions) ([]*{{$m}}, *ListPage, error) {
+	return f.list(func(m *{{$m}}) bool { return true }, opts)
+}
+
+// Add stores a copy of a new record.
+func (f *Fake{{$m}}DB) Add(ctx context.Context{{ if $ut.DynamicTableName }}, tableName string{{ end }}, model *{{$m}}) error {
+	if err := model.Validate(); err != nil {
+		return validationError("{{$m}}", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+{{ $ut.FakeKeyGeneration }}
+	key := {{$ut.CacheKey "model"}}
+	if _, ok := f.records[key]; ok {
+		return &Error{Kind: ErrConflict, Model: "{{$m}}", Err: fmt.Errorf("duplicate key %s", key)}
+	}
+{{ $ut.FakeTimestamps "model" true }}
+	obj := *model
+	f.records[key] = &obj
+	return nil
+}
+
+// Update modifies a single record, blank fields of model are left alone.
+func (f *Fake{{$m}}DB) Update(ctx context.Context{{ if $ut.DynamicTableName }}, tableName string{{ end }}, model *{{$m}}) error {
+	if err := model.Validate(); err != nil {
+		return validationError("{{$m}}", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj := f.get({{$ut.CacheKey "model"}})
+	if obj == nil {
+		return {{$ut.StorageError "gorm.ErrRecordNotFound"}}
+	}
+{{ $ut.FakeUpdates }}
+{{ $ut.FakeTimestamps "obj" false }}
+	return nil
+}
+
+// Delete removes a single record{{if $ut.SoftDelete}}, setting its DeletedAt{{end}}.
+func (f *Fake{{$m}}DB) Delete(ctx context.Context{{ if $ut.DynamicTableName }}, tableName string{{ end }}, {{$ut.PKAttributes}}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := {{$ut.CacheKey ""}}
+	obj := f.get(key)
+	if obj == nil {
+		return {{$ut.StorageError "gorm.ErrRecordNotFound"}}
+	}
+	{{if $ut.SoftDelete}}now := time.Now()
+	obj.DeletedAt = &now{{else}}delete(f.records, key){{end}}
+	return nil
+}
+{{ range $bfn, $bf := $ut.BuiltFrom }}
+// UpdateFrom{{$bfn}} applies non-nil changes from {{goify $bfn true}} to the record.
+func (f *Fake{{$m}}DB) UpdateFrom{{$bfn}}(ctx context.Context{{ if $ut.DynamicTableName}}, tableName string{{ end }}, payload *app.{{goify $bfn true}}, {{$ut.PKAttributes}}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stored := f.get({{$ut.CacheKey ""}})
+	if stored == nil {
+		return {{$ut.StorageError "gorm.ErrRecordNotFound"}}
+	}
+	obj := *stored
+	{{ fapm $ut $bf "app" "payload" "payload" "obj" }}
+
+	if err := obj.Validate(); err != nil {
+		return validationError("{{$m}}", err)
+	}
+{{ $ut.FakeTimestamps "obj" false }}
+	*stored = obj
+	return nil
+}
+{{ end }}{{ range $rname, $rmt := $ut.RenderTo }}{{ range $vname, $view := $ut.RenderedViews $rname }}{{/*
+*/}}{{ $mt := printf "%s%s" (goify $rmt.TypeName true) (or (and (ne $vname "default") (goify $vname true)) "") }}{{/*
+*/}}{{ $conv := printf "%sTo%s%s" $m (goify $rmt.UserTypeDefinition.TypeName true) (or (and (ne $vname "default") (goify $vname true)) "") }}
+// List{{$mt}} returns {{if $.LegacyLists}}an array{{else}}a page{{end}} of view: {{$vname}}.
+func (f *Fake{{$m}}DB) List{{$mt}}(ctx context.Context{{ if $ut.DynamicTableName}}, tableName string{{ end }}{{/*
+*/}}{{range $nm, $bt := $ut.BelongsTo}}, {{$ut.BelongsToAttributes $bt.ModelName}}{{end}}{{if not $.LegacyLists}}, opts *{{$m}}ListOptions{{end}}){{/*
+*/}} {{if $.LegacyLists}}[]*app.{{$mt}}{{else}}([]*app.{{$mt}}, *ListPage, error){{end}} {
+	var objs []*app.{{$mt}}
+	native, {{if $.LegacyLists}}_{{else}}page{{end}}, err := f.list(func(m *{{$m}}) bool {
+{{range $nm, $bt := $ut.BelongsTo}}		if {{$ut.BelongsToGuard $bt.ModelName}} && !({{$ut.BelongsToMatch $bt.ModelName "m"}}) {
+			return false
+		}
+{{end}}		return true
+	}, {{if $.LegacyLists}}nil{{else}}opts{{end}})
+	if err != nil {
+		return {{if $.LegacyLists}}objs{{else}}nil, nil, err{{end}}
+	}
+	for _, t := range native {
+		objs = append(objs, t.{{$conv}}())
+	}
+	return objs{{if not $.LegacyLists}}, page, nil{{end}}
+}
+
+// One{{$mt}} builds the {{$vname}} view of media type {{$rmt.TypeName}} from a record.
+func (f *Fake{{$m}}DB) One{{$mt}}(ctx context.Context{{ if $ut.DynamicTableName}}, tableName string{{ end }}, {{$ut.OneAttributes}}) (*app.{{$mt}}, error) {
+	f.mu.Lock()
+	obj := f.get({{$ut.CacheKey ""}})
+{{range $nm, $bt := $ut.BelongsTo}}	if obj != nil && {{$ut.BelongsToGuard $bt.ModelName}} && !({{$ut.BelongsToMatch $bt.ModelName "obj"}}) {
+		obj = nil
+	}
+{{end}}	var native {{$m}}
+	if obj != nil {
+		native = *obj
+	}
+	f.mu.Unlock()
+	if obj == nil {
+		return nil, {{$ut.StorageError "gorm.ErrRecordNotFound"}}
+	}
+	view := *native.{{$conv}}()
+	return &view, nil
+}
+{{ end }}{{ end }}`
+
+	// mockT generates the call recording mock of the storage of a model.
+	// template input: UserTypeTemplateData
+	mockT = `{{$ut := .UserType}}{{$m := $ut.ModelName}}{{$methods := $ut.StorageMethods .LegacyLists}}// Mock{{$m}}Storage is a {{$m}}Storage recording its calls.  Each method
+// calls its stub func when set and returns zero values otherwise.
+type Mock{{$m}}Storage struct {
+	mu sync.Mutex
+{{ range $methods }}
+	// {{.Name}}Func stubs {{.Name}}.
+	{{.Name}}Func func({{.ParamTypes}}) {{.ResultTypes}}
+	// {{.Name}}Calls records the calls to {{.Name}}.
+	{{.Name}}Calls []Mock{{$m}}{{.Name}}Call
+{{ end }}}
+
+var _ {{$m}}Storage = (*Mock{{$m}}Storage)(nil)
+{{ range $methods }}
+// Mock{{$m}}{{.Name}}Call holds the arguments of a call to {{.Name}}.
+type Mock{{$m}}{{.Name}}Call struct {
+{{ range .Params }}	{{goify .Name true}} {{.Type}}
+{{ end }}}
+
+// {{.Name}} records the call and calls {{.Name}}Func.
+func (m *Mock{{$m}}Storage) {{.Signature}} {
+	m.mu.Lock()
+	m.{{.Name}}Calls = append(m.{{.Name}}Calls, Mock{{$m}}{{.Name}}Call{ {{- range $i, $p := .Params}}{{if $i}}, {{end}}{{goify $p.Name true}}: {{$p.Name}}{{end -}} })
+	stub := m.{{.Name}}Func
+	m.mu.Unlock()
+	if stub != nil {
+		return stub({{.ParamNames}})
+	}
+	return {{.ZeroResults}}
+}
+{{ end }}`
+
+	// factoryT generates the factory of the records of a model.
+	// template input: UserTypeTemplateData
+	factoryT = `{{$ut := .UserType}}{{$m := $ut.ModelName}}{{$parents := $ut.FactoryParents}}// {{$m}}Factory builds valid {{$m}} records for tests and seeds.  The
+// fields satisfy the validations of the model and unique columns get the
+// sequence number of the record where the validations allow it.
+type {{$m}}Factory struct {
+{{range $parents}}	// {{.}} creates the {{.}} of the records created without one.
+	{{.}} *{{.}}Factory
+{{end}}
+	mu sync.Mutex
+	n  int
+}
+
+// New{{$m}}Factory creates a {{$m}} factory{{if $parents}} and the factories of its parents{{end}}.
+func New{{$m}}Factory() *{{$m}}Factory {
+	return &{{$m}}Factory{ {{- range $parents}}
+		{{.}}: New{{.}}Factory(),{{end}}
+	}
+}
+
+// next returns the sequence number of the next record.
+func (f *{{$m}}Factory) next() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.n++
+	return f.n
+}
+
+// Build returns a new {{$m}} modified by the overrides, it is not stored.
+func (f *{{$m}}Factory) Build(overrides ...func(m *{{$m}})) *{{$m}} {
+	m := &{{$m}}{}
+	{{$ut.FactoryFields}}
+	for _, override := range overrides {
+		override(m)
+	}
+	return m
+}
+
+// Create builds a {{$m}} and adds it to the storage of repo{{if $parents}}, creating
+// the parents the overrides leave unset first{{end}}.
+func (f *{{$m}}Factory) Create(ctx context.Context, repo {{$ut.Parent.StorageName}}, overrides ...func(m *{{$m}})) (*{{$m}}, error) {
+	m := f.Build(overrides...)
+{{range $parents}}	{{$ut.FactoryParentCode .}}
+{{end}}	if err := repo.{{$m}}().Add(ctx, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+`
+
+	// adapterT generates the conversion functions of the adapters of a model.
+	// template input: UserTypeTemplateData
+	adapterT = `{{$ut := .UserType}}{{$m := $ut.ModelName}}{{$v := $ut.LowerName}}{{range $ut.MediaTypeAdapters}}{{$mt := goify .Left.TypeName true}}// {{.Name}} converts a {{$m}} into a {{.Left.TypeName}} media type.{{if .Description}}
+// {{.Description}}{{end}}
+func {{.Name}}(src *{{$m}}) *app.{{$mt}} {
+	if src == nil {
+		return nil
+	}
+	{{$v}} := &app.{{$mt}}{}
+	{{.Assignments $v}}
+	return {{$v}}
+}
+
+{{end}}{{range $ut.UserTypeAdapters}}// {{.Name}} converts a {{.Left.TypeName}} user type into a {{$m}}.{{if .Description}}
+// {{.Description}}{{end}}
+func {{.Name}}(src *app.{{goify .Left.TypeName true}}) *{{$m}} {
+	if src == nil {
+		return nil
+	}
+	m := &{{$m}}{}
+	{{.Assignments}}
+	return m
+}
+
+{{end}}{{range $ut.PayloadAdapters}}// {{.Name}} converts a {{.Left.TypeName}} payload into a {{$m}}.{{if .Description}}
+// {{.Description}}{{end}}
+func {{.Name}}(src *app.{{goify .Left.TypeName true}}) *{{$m}} {
+	if src == nil {
+		return nil
+	}
+	m := &{{$m}}{}
+	{{.Assignments}}
+	return m
+}
+
+{{end}}`
+
+	// roundTripT generates the tests converting the records of a model to
+	// the types it is built from and back.
+	// template input: UserTypeTemplateData
+	roundTripT = `{{$ut := .UserType}}{{$m := $ut.ModelName}}{{range $ut.RoundTripTypes}}{{$t := .TypeName}}// Test{{$m}}RoundTrip{{$t}} checks that the fields of a {{$m}} converted to a
+// {{goify $t true}} survive the conversion back.
+func Test{{$m}}RoundTrip{{$t}}(t *testing.T) {
+	m := New{{$m}}Factory().Build({{$values := $ut.RoundTripValues .}}{{if $values}}func(m *{{$m}}) {
+		{{$values}}
+	}{{end}})
+	got := {{$m}}From{{$t}}(m.{{$m}}To{{$t}}())
+{{range $ut.RoundTripFields .}}	if !reflect.DeepEqual(got.{{.}}, m.{{.}}) {
+		t.Errorf("{{.}}: got %v, want %v", got.{{.}}, m.{{.}})
+	}
+{{end}}}
+
+{{end}}`
+
+	// seedT generates the function seeding a store.
+	// template input: *RelationalStoreDefinition
+	seedT = `{{$store := goify .Name true}}// Seed{{$store}} upserts the seed rows of the {{.Name}} store in a transaction,
+// the rows of the parents first.  The rows are matched with the records on
+// their primary key or unique field: missing records are created and the
+// others updated, so seeding again changes nothing.
+func Seed{{$store}}(ctx context.Context, db *gorm.DB) error {
+	rows := []struct {
+		model  string
+		value  interface{}
+		key    map[string]interface{}
+		values map[string]interface{}
+	}{
+		{{.SeedRows}}
+	}
+	tx := db.BeginTx(ctx, nil)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	for _, row := range rows {
+		if err := tx.Unscoped().Where(row.key).Assign(row.values).FirstOrCreate(row.value).Error; err != nil {
+			tx.Rollback()
+			return storageError({{printf "%q" .Type}}, row.model, err)
+		}
+	}
+	return tx.Commit().Error
+}
+`
+
+	// testHelpersT generates the helpers shared by the test repositories.
+	testHelpersT = `// database is the in-process SQLite database of a store, created once per
+// test binary.  The tests using it run one at a time, each in a
+// transaction rolled back when the test ends.
+type database struct {
+	name   string
+	schema []string
+
+	once sync.Once
+	db   *gorm.DB
+	err  error
+	mu   sync.Mutex // held by the test running
+}
+
+// open opens the database and creates the tables of the store.
+func (d *database) open() (*gorm.DB, error) {
+	d.once.Do(func() {
+		// The in-memory database lives as long as one of its connections.
+		d.db, d.err = gorm.Open("sqlite3", fmt.Sprintf("file:%s?mode=memory&cache=shared&_foreign_keys=1", d.name))
+		if d.err != nil {
+			return
+		}
+		d.db.DB().SetConnMaxLifetime(0)
+		d.db.Callback().Create().Before("gorm:create").Register("{{.}}:generate_uuid", generateUUID)
+		for _, stmt := range d.schema {
+			if err := d.db.Exec(stmt).Error; err != nil {
+				d.err = fmt.Errorf("%s: %s", err, stmt)
+				return
+			}
+		}
+	})
+	return d.db, d.err
+}
+
+// begin waits for the running test to end and returns the database and a
+// transaction rolled back when the test ends.
+func (d *database) begin(t testing.TB) (*gorm.DB, *gorm.DB) {
+	t.Helper()
+	db, err := d.open()
+	if err != nil {
+		t.Fatalf("can't create the %s database: %s", d.name, err)
+	}
+	d.mu.Lock()
+	tx := db.BeginTx(context.Background(), nil)
+	if err := tx.Error; err != nil {
+		d.mu.Unlock()
+		t.Fatalf("can't begin a transaction of the %s database: %s", d.name, err)
+	}
+	t.Cleanup(func() {
+		tx.Rollback()
+		d.mu.Unlock()
+	})
+	return db, tx
+}
+
+// generateUUID stands in for the UUID primary keys generated by Postgres,
+// SQLite has no gen_random_uuid.
+func generateUUID(scope *gorm.Scope) {
+	field := scope.PrimaryField()
+	if field == nil || !field.IsBlank {
+		return
+	}
+	if _, ok := field.Field.Interface().(uuid.UUID); ok {
+		scope.Err(field.Set(uuid.Must(uuid.NewV4())))
+	}
+}
+`
+
+	// testRepositoryT generates the test repository of a store.
+	// template input: map with the store and the models package name
+	testRepositoryT = `{{$store := .Store}}{{$repo := $store.RepositoryName}}{{$db := printf "%sDB" (goify $store.Name false)}}// {{$db}} holds the tables of the {{$store.Name}} store.
+var {{$db}} = &database{
+	name: {{printf "%q" (printf "%s_%s" .Pkg $store.Name)}},
+	schema: []string{
+{{range $store.SQLiteSchema}}		{{printf "%q" .}},
+{{end}}	},
+}
+
+// New{{$repo}} returns a repository of the {{$store.Name}} store for a test.
+// Its storages use an in-process SQLite database holding the tables of the
+// store, in a transaction rolled back when the test ends: the tests using
+// the repository run one at a time and see none of the changes of the
+// others.
+func New{{$repo}}(t testing.TB) *{{.Pkg}}.{{$repo}} {
+	t.Helper()
+	pool, tx := {{$db}}.begin(t)
+	return {{.Pkg}}.New{{$repo}}Tx(pool, tx)
+}
 `
 
 	userHelperT = `{{define "Media"}}` + mediaT + `{{end}}` + `{{$ut := .UserType}}{{$ap := .AppPkg}}
@@ -604,39 +1691,41 @@ func (m {{$ut.ModelName}}) GetRole() string {
 {{end}}
 
 {{ range $rname, $rmt := $ut.RenderTo }}
-{{ range $vname, $view := $rmt.Views}}
+{{ range $vname, $view := $ut.RenderedViews $rname }}
 {{ $mtd := $ut.Project $rname $vname }}
 
-{{template "Media" (newMediaTemplate $rmt $vname $view $ut)}}
+{{template "Media" (newMediaTemplate $rmt $vname $view $ut $.LegacyLists)}}
 {{end}}{{end}}
 
 `
 
 	mediaT = `// MediaType Retrieval Functions
 
-// List{{goify .Media.TypeName true}}{{if not (eq .ViewName "default")}}{{goify .ViewName true}}{{end}} returns an array of view: {{.ViewName}}.
-func (m *{{.Model.ModelName}}DB) List{{goify .Media.TypeName true}}{{if not (eq .ViewName "default")}}{{goify .ViewName true}}{{end}}{{/*
+{{ if .LegacyLists }}// List{{goify .Media.TypeName true}}{{if not (eq .ViewName "default")}}{{goify .ViewName true}}{{end}} returns an array of view: {{.ViewName}}.
+// Errors are logged, the list is empty then.
+{{ else }}// List{{goify .Media.TypeName true}}{{if not (eq .ViewName "default")}}{{goify .ViewName true}}{{end}} returns a page of view: {{.ViewName}}, all of them when opts is nil.
+{{ end }}func (m *{{.Model.ModelName}}DB) List{{goify .Media.TypeName true}}{{if not (eq .ViewName "default")}}{{goify .ViewName true}}{{end}}{{/*
 */}} (ctx context.Context{{ if .Model.DynamicTableName}}, tableName string{{ end }}{{/*
-*/}} {{$mod:=.Model}}{{range $nm, $bt := .Model.BelongsTo}},{{goify (printf "%s%s" $bt.ModelName "ID") false}} {{ goDatatypeByModel $mod $bt.ModelName }}{{end}}){{/*
-*/}} []*app.{{goify .Media.TypeName true}}{{if not (eq .ViewName "default")}}{{goify .ViewName true}}{{end}}{
+*/}} {{$mod:=.Model}}{{range $nm, $bt := .Model.BelongsTo}}, {{$mod.BelongsToAttributes $bt.ModelName}}{{end}}{{if not .LegacyLists}}, opts *{{.Model.ModelName}}ListOptions{{end}}){{/*
+*/}} {{if .LegacyLists}}[]*app.{{goify .Media.TypeName true}}{{if not (eq .ViewName "default")}}{{goify .ViewName true}}{{end}}{{/*
+*/}}{{else}}([]*app.{{goify .Media.TypeName true}}{{if not (eq .ViewName "default")}}{{goify .ViewName true}}{{end}}, *ListPage, error){{end}} {
 	defer goa.MeasureSince([]string{"goa","db","{{goify .Media.TypeName false}}", "list{{goify .Media.TypeName false}}{{if eq .ViewName "default"}}{{else}}{{goify .ViewName false}}{{end}}"}, time.Now())
 
-	var native []*{{goify .Model.ModelName true}}
 	var objs []*app.{{goify .Media.TypeName true}}{{if not (eq .ViewName "default")}}{{goify .ViewName true}}{{end}}{{$ctx:= .}}
-	err := m.Db.Scopes({{range $nm, $bt := .Model.BelongsTo}}{{/*
-*/}}{{$ctx.Model.ModelName}}FilterBy{{goify $bt.ModelName true}}({{goify (printf "%s%s" $bt.ModelName "ID") false}}, m.Db), {{end}}){{/*
-*/}}.Table({{ if .Model.DynamicTableName }}tableName{{else}}m.TableName(){{ end }}).{{ range $ln, $lv := .Media.Links }}Preload("{{goify $ln true}}").{{end}}Find(&native).Error
-{{/* //	err := m.Db.Table({{ if .Model.DynamicTableName }}tableName{{else}}m.TableName(){{ end }}).{{ range $ln, $lv := .Media.Links }}Preload("{{goify $ln true}}").{{end}}Find(&objs).Error */}}
+	native, {{if .LegacyLists}}_{{else}}page{{end}}, err := m.list(m.Db.Scopes({{range $nm, $bt := .Model.BelongsTo}}{{/*
+*/}}{{$ctx.Model.ModelName}}FilterBy{{goify $bt.ModelName true}}({{$ctx.Model.BelongsToFields $bt.ModelName}}, m.Db), {{end}}){{/*
+*/}}.Table({{ if .Model.DynamicTableName }}tableName{{else}}m.TableName(){{ end }}){{ range $ln, $lv := .Media.Links }}.Preload("{{goify $ln true}}"){{end}}{{ range .ListPreloads }}.Preload("{{.}}"){{end}}{{/*
+*/}}{{ with viewSelect .Model .View }}.Select({{printf "%q" .}}){{end}}, {{if .LegacyLists}}nil{{else}}opts{{end}})
 	if err != nil {
 		goa.LogError(ctx, "error listing {{.Model.ModelName}}", "error", err.Error())
-		return objs
+		return {{if .LegacyLists}}objs{{else}}nil, nil, err{{end}}
 	}
 
 	for _, t := range native {
 		objs = append(objs, t.{{.Model.ModelName}}To{{goify .Media.UserTypeDefinition.TypeName true}}{{if eq .ViewName "default"}}{{else}}{{goify .ViewName true}}{{end}}())
 	}
 
-	return objs
+	return objs{{if not .LegacyLists}}, page, nil{{end}}
 }
 
 // {{$.Model.ModelName}}To{{goify .Media.UserTypeDefinition.TypeName true}}{{if not (eq .ViewName "default")}}{{goify .ViewName true}}{{end}}{{/*
@@ -651,23 +1740,23 @@ func (m *{{.Model.ModelName}}) {{$.Model.ModelName}}To{{goify .Media.UserTypeDef
 
 // One{{goify .Media.TypeName true}}{{if not (eq .ViewName "default")}}{{goify .ViewName true}}{{end}} loads a {{.Model.ModelName}} and builds the {{.ViewName}} view of media type {{.Media.TypeName}}.
 func (m *{{.Model.ModelName}}DB) One{{goify .Media.TypeName true}}{{if not (eq .ViewName "default")}}{{goify .ViewName true}}{{end}}{{/*
-*/}} (ctx context.Context{{ if .Model.DynamicTableName}}, tableName string{{ end }},{{.Model.PKAttributes}}{{/*
-*/}}{{$mod:=.Model}}{{range $nm, $bt := .Model.BelongsTo}},{{goify (printf "%s%s" $bt.ModelName "ID") false}} {{ goDatatypeByModel $mod $bt.ModelName }}{{end}}){{/*
+*/}} (ctx context.Context{{ if .Model.DynamicTableName}}, tableName string{{ end }}, {{.Model.OneAttributes}}){{/*
 */}} (*app.{{goify .Media.TypeName true}}{{if not (eq .ViewName "default")}}{{goify .ViewName true}}{{end}}, error){
 	defer goa.MeasureSince([]string{"goa","db","{{goify .Media.TypeName false}}", "one{{goify .Media.TypeName false}}{{if not (eq .ViewName "default")}}{{goify .ViewName false}}{{end}}"}, time.Now())
 
 	var native {{.Model.ModelName}}
-	err := m.Db.Scopes({{range $nm, $bt := .Model.BelongsTo}}{{$ctx.Model.ModelName}}FilterBy{{goify $bt.ModelName true}}({{goify (printf "%s%s" $bt.ModelName "ID") false}}, m.Db), {{end}}).Table({{ if .Model.DynamicTableName }}tableName{{else}}m.TableName(){{ end }}){{range $na, $hm:= .Model.HasMany}}.Preload("{{plural $hm.ModelName}}"){{end}}{{range $nm, $bt := .Model.BelongsTo}}.Preload("{{$bt.ModelName}}"){{end}}.Where("{{.Model.PKWhere}}",{{.Model.PKWhereFields}}).Find(&native).Error
+	err := m.Db.Scopes({{range $nm, $bt := .Model.BelongsTo}}{{$ctx.Model.ModelName}}FilterBy{{goify $bt.ModelName true}}({{$ctx.Model.BelongsToFields $bt.ModelName}}, m.Db), {{end}}).Table({{ if .Model.DynamicTableName }}tableName{{else}}m.TableName(){{ end }}){{range $na, $hm:= .Model.HasMany}}.Preload("{{plural $hm.ModelName}}"){{end}}{{range $nm, $bt := .Model.BelongsTo}}.Preload("{{$bt.ModelName}}"){{end}}{{range .OnePreloads}}.Preload("{{.}}"){{end}}{{/*
+*/}}{{ if not .Model.Cached }}{{ with viewSelect .Model .View }}.Select({{printf "%q" .}}){{end}}{{end}}.Where({{printf "%q" .Model.PKWhere}},{{.Model.PKWhereFields}}).Find(&native).Error
 
-	if err != nil && err !=  gorm.ErrRecordNotFound {
+	if err != nil {
 		goa.LogError(ctx, "error getting {{.Model.ModelName}}", "error", err.Error())
-		return nil, err
+		return nil, {{.Model.StorageError "err"}}
 	}
 	{{ if .Model.Cached }} go func(){
-		m.cache.Set(strconv.Itoa(native.ID), &native, cache.DefaultExpiration)
+		m.cache.Set({{.Model.CacheKey "native"}}, &native, cache.DefaultExpiration)
 	}() {{ end }}
 	view := *native.{{.Model.ModelName}}To{{goify .Media.UserTypeDefinition.TypeName true}}{{if not (eq .ViewName "default")}}{{goify .ViewName true}}{{end}}()
-	return &view, err
+	return &view, nil
 }
 `
 )